@@ -6,6 +6,8 @@ require (
 	github.com/pterm/pterm v0.12.81
 	github.com/stretchr/testify v1.10.0
 	github.com/urfave/cli/v2 v2.27.6
+	go.etcd.io/bbolt v1.4.0
+	golang.org/x/net v0.41.0
 )
 
 require (
@@ -6,6 +6,8 @@ import (
 	"strings"
 
 	"github.com/mattjh1/psi-map/internal/constants"
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/mattjh1/psi-map/internal/utils"
 	"github.com/urfave/cli/v2"
 )
 
@@ -17,19 +19,30 @@ func analyzeCommand() *cli.Command {
 		Name:      "analyze",
 		Aliases:   []string{"run"},
 		Usage:     "Analyze sitemap and generate reports",
-		ArgsUsage: "[flags] <sitemap_url_or_file>",
+		ArgsUsage: "[flags] <sitemap_url_or_file... | --manifest file | --crawl start_url | --from-dir dir --base-url url>",
 		Description: `Analyze a sitemap and generate reports in various formats.
         
 Examples:
   psi-map analyze sitemap.xml
   psi-map analyze -o html sitemap.xml
   psi-map analyze -o json --output-dir ./reports sitemap.xml
-  psi-map analyze -o stdout https://example.com/sitemap.xml`,
-		Flags: []cli.Flag{
+  psi-map analyze -o stdout https://example.com/sitemap.xml
+  psi-map analyze -o html https://a.example/sitemap.xml https://b.example/sitemap.xml
+  psi-map analyze -o html --manifest sites.txt
+  psi-map analyze --crawl https://example.com --crawl-depth 3
+  psi-map analyze --from-dir ./public --base-url https://preview.example.com
+  psi-map analyze --exclude '/tag/**' --exclude 're:/page/\d+' sitemap.xml
+  psi-map analyze --sample 3 --sample-mode lastmod sitemap.xml
+  psi-map analyze --trickle 500 --cache-ttl 168 sitemap.xml
+  psi-map analyze --hreflang sitemap.xml
+  psi-map analyze --interactive sitemap.xml
+  psi-map analyze --preflight sitemap.xml
+  psi-map analyze --shard 3/8 --name shard-3 sitemap.xml`,
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:    "output",
 				Aliases: []string{"o"},
-				Usage:   "Output format: json, html, stdout (default: json)",
+				Usage:   "Output format: json, html, csv, stdout (default: json)",
 				Value:   constants.JSON,
 			},
 			&cli.StringFlag{
@@ -53,10 +66,18 @@ Examples:
 				Value: constants.DefaultTTLHours,
 				Usage: "Cache TTL in hours (0 = no expiration)",
 			},
-		},
+			&cli.StringFlag{
+				Name:  "shard",
+				Usage: "Only analyze shard i of n of the URL list, e.g. 3/8, to split a run across jobs (combine with merge)",
+			},
+			&cli.BoolFlag{
+				Name:  "interactive",
+				Usage: "Pick the sections or pages to analyze from the parsed URLs before spending quota",
+			},
+		}, append(sourceFlags(), sharedFlags()...)...),
 		Action: func(c *cli.Context) error {
-			if c.NArg() < 1 {
-				return fmt.Errorf("sitemap URL or file path is required")
+			if err := validateInputSource(c); err != nil {
+				return err
 			}
 
 			// Handle output logic
@@ -68,16 +89,231 @@ Examples:
 	}
 }
 
+// sourceFlags returns the URL source and selection flags shared by analyze,
+// server and watch
+func sourceFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "manifest",
+			Usage: "File listing sites to analyze in one run, one \"[name] <sitemap>\" per line",
+		},
+		&cli.StringSliceFlag{
+			Name:  "include",
+			Usage: "Only analyze URLs matching this glob or re:regex (repeatable)",
+		},
+		&cli.StringFlag{
+			Name:  "include-file",
+			Usage: "Read include rules from a file, one per line (e.g. saved by --interactive)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "exclude",
+			Usage: "Skip URLs matching this glob or re:regex (repeatable)",
+		},
+		&cli.BoolFlag{
+			Name:  "hreflang",
+			Usage: "Also analyze hreflang alternates listed in the sitemap and compare scores across locales",
+		},
+		&cli.IntFlag{
+			Name:  "sample",
+			Usage: "Cluster URLs by path pattern and analyze at most N URLs per cluster (0 = analyze all)",
+		},
+		&cli.StringFlag{
+			Name:  "sample-mode",
+			Usage: "How to pick sampled URLs: random, priority, lastmod",
+			Value: types.SampleRandom,
+		},
+		&cli.Int64Flag{
+			Name:  "sample-seed",
+			Usage: "Seed for random sampling (change it to draw a different sample)",
+			Value: constants.DefaultSampleSeed,
+		},
+		&cli.IntFlag{
+			Name:  "trickle",
+			Usage: "Analyze at most N new or expired URLs this run and serve the rest of the site from cache (0 = analyze all)",
+		},
+		&cli.StringFlag{
+			Name:  "trickle-order",
+			Usage: "Which cached URLs --trickle re-analyzes first once new URLs are done: oldest, volatile",
+			Value: types.TrickleOldest,
+		},
+		&cli.StringFlag{
+			Name:  "crawl",
+			Usage: "Discover URLs by crawling same-origin links from this start URL instead of a sitemap",
+		},
+		&cli.IntFlag{
+			Name:  "crawl-depth",
+			Usage: "Maximum link depth to follow from the start URL",
+			Value: constants.DefaultCrawlDepth,
+		},
+		&cli.IntFlag{
+			Name:  "crawl-max-pages",
+			Usage: "Maximum number of pages to fetch while crawling",
+			Value: constants.DefaultCrawlMaxPages,
+		},
+		&cli.IntFlag{
+			Name:  "crawl-concurrency",
+			Usage: "Maximum number of concurrent crawl requests",
+			Value: constants.DefaultCrawlConcurrency,
+		},
+		&cli.StringSliceFlag{
+			Name:  "crawl-include",
+			Usage: "Only report crawled URLs matching this glob or re:regex (repeatable)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "crawl-exclude",
+			Usage: "Skip crawled URLs matching this glob or re:regex (repeatable)",
+		},
+		&cli.BoolFlag{
+			Name:  "crawl-ignore-robots",
+			Usage: "Do not respect robots.txt while crawling",
+		},
+		&cli.StringFlag{
+			Name:  "from-dir",
+			Usage: "Analyze the HTML pages of a built static site directory instead of a sitemap",
+		},
+		&cli.StringFlag{
+			Name:  "base-url",
+			Usage: "URL the --from-dir build is deployed at, e.g. https://preview.example.com",
+		},
+		&cli.BoolFlag{
+			Name:  "clean-urls",
+			Usage: "Map --from-dir pages like about.html to /about instead of /about.html",
+		},
+	}
+}
+
+// validateInputSource checks that exactly one URL source was given
+func validateInputSource(c *cli.Context) error {
+	sources := 0
+	for _, set := range []bool{c.NArg() > 0 || c.String("manifest") != "", c.String("crawl") != "", c.String("from-dir") != ""} {
+		if set {
+			sources++
+		}
+	}
+	switch {
+	case sources == 0:
+		return fmt.Errorf("sitemap URL or file path is required (or use --crawl or --from-dir)")
+	case sources > 1:
+		return fmt.Errorf("sitemap arguments or --manifest, --crawl and --from-dir cannot be combined")
+	case c.String("from-dir") != "" && c.String("base-url") == "":
+		return fmt.Errorf("--from-dir requires --base-url")
+	}
+	return nil
+}
+
 func handleOutputFlags(c *cli.Context) error {
 	format := strings.ToLower(c.String("output"))
 
 	// Validate format
 	switch format {
-	case constants.STDOUT, constants.HTML, constants.JSON:
+	case constants.STDOUT, constants.HTML, constants.JSON, constants.CSV:
 		// Valid formats
 	default:
-		return fmt.Errorf("unsupported output format: %s (supported: json, html, stdout)", format)
+		return fmt.Errorf("unsupported output format: %s (supported: json, html, csv, stdout)", format)
 	}
 
 	return nil
 }
+
+// sharedFlags returns the URL preparation and run flags shared by analyze,
+// server and watch
+func sharedFlags() []cli.Flag {
+	flags := append(normalizeFlags(), rewriteFlags()...)
+	flags = append(flags, preflightFlags()...)
+	return append(flags,
+		&cli.StringFlag{
+			Name:  "events",
+			Usage: "Write run events (queued, started, retry, completed, ...) to this file as JSON lines while the run progresses",
+		},
+		&cli.IntFlag{
+			Name:  "retries",
+			Usage: "How many more times a strategy is requested after a rate limit (429), a server error (5xx) or a timeout, waiting 5s longer each time (default: never retry)",
+		},
+		&cli.BoolFlag{
+			Name:  "adaptive",
+			Usage: "Start with 2 workers and add more while PSI stays fast, halving them on rate limits, up to --workers (default 16 with this flag)",
+		},
+		&cli.DurationFlag{
+			Name:  "failure-ttl",
+			Usage: "How long a failed mobile or desktop result stays cached before that strategy alone is analyzed again (0 = every run)",
+			Value: constants.DefaultFailureTTL,
+		},
+	)
+}
+
+// normalizeFlags returns the URL normalization flags shared by analyze and server
+func normalizeFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:  "trailing-slash",
+			Usage: "Trailing slash policy before deduplicating URLs: keep, add, strip",
+			Value: types.TrailingSlashKeep,
+		},
+		&cli.StringSliceFlag{
+			Name:  "drop-param",
+			Usage: "Query parameter to remove before deduplicating URLs, a trailing * matches a prefix (repeatable)",
+			Value: cli.NewStringSlice("utm_*", "gclid", "fbclid"),
+		},
+		&cli.BoolFlag{
+			Name:  "keep-fragment",
+			Usage: "Keep #fragments when deduplicating URLs",
+		},
+		&cli.BoolFlag{
+			Name:  "keep-host-case",
+			Usage: "Do not lowercase hostnames when deduplicating URLs",
+		},
+	}
+}
+
+// preflightFlags returns the reachability check flags shared by analyze and server
+func preflightFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "preflight",
+			Usage: "Skip URLs that fail, redirect, are not HTML or resolve to private IPs before calling PSI",
+		},
+		&cli.IntFlag{
+			Name:  "preflight-concurrency",
+			Usage: "Maximum number of concurrent preflight requests",
+			Value: constants.DefaultStatusConcurrency,
+		},
+	}
+}
+
+// rewriteFlags returns the URL rewrite flags shared by analyze and server
+func rewriteFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringSliceFlag{
+			Name:  "rewrite-host",
+			Usage: "Replace a sitemap host before analysis, e.g. www.example.com=staging.example.com (repeatable)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "rewrite-path",
+			Usage: "Replace a path prefix before analysis, e.g. /en=/staging/en (repeatable)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "add-param",
+			Usage: "Set a query parameter on every analyzed URL, e.g. preview=1 (repeatable)",
+		},
+	}
+}
+
+// rewriteConfig builds the rewrite rules from the flags, or nil when none are set
+func rewriteConfig(c *cli.Context) (*types.RewriteConfig, error) {
+	hosts, err := utils.ParseRewritePairs(c.StringSlice("rewrite-host"), "rewrite-host")
+	if err != nil {
+		return nil, err
+	}
+	paths, err := utils.ParseRewritePairs(c.StringSlice("rewrite-path"), "rewrite-path")
+	if err != nil {
+		return nil, err
+	}
+	params, err := utils.ParseRewritePairs(c.StringSlice("add-param"), "add-param")
+	if err != nil {
+		return nil, err
+	}
+	if len(hosts) == 0 && len(paths) == 0 && len(params) == 0 {
+		return nil, nil
+	}
+	return &types.RewriteConfig{Hosts: hosts, Paths: paths, Params: params}, nil
+}
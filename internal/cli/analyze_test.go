@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/urfave/cli/v2"
+)
+
+// validateCommand runs a command line through the app's flag parsing and
+// returns the validation error, without analyzing anything
+func validateCommand(cmd *cli.Command, args ...string) error {
+	cmd.Action = func(c *cli.Context) error {
+		if err := validateInputSource(c); err != nil {
+			return err
+		}
+		if c.IsSet("output") {
+			return handleOutputFlags(c)
+		}
+		return nil
+	}
+	app := &cli.App{Name: "psi-map", Commands: []*cli.Command{cmd}}
+	return app.Run(append([]string{"psi-map", cmd.Name}, args...))
+}
+
+func TestAnalyzeCommand_OutputFormats(t *testing.T) {
+	for _, format := range []string{"json", "html", "csv", "stdout", "CSV"} {
+		assert.NoError(t, validateCommand(analyzeCommand(), "-o", format, "sitemap.xml"), format)
+	}
+
+	err := validateCommand(analyzeCommand(), "--output", "xml", "sitemap.xml")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "csv")
+}
+
+func TestServerCommand_AcceptsSourceFlags(t *testing.T) {
+	assert.NoError(t, validateCommand(serverCommand(), "--sample", "3", "--trickle", "100", "sitemap.xml"))
+	assert.NoError(t, validateCommand(serverCommand(), "--crawl", "https://example.com"))
+	assert.NoError(t, validateCommand(serverCommand(), "--from-dir", "public", "--base-url", "https://example.com"))
+
+	err := validateCommand(serverCommand(), "--crawl", "https://example.com", "sitemap.xml")
+	assert.ErrorContains(t, err, "cannot be combined")
+	assert.Error(t, validateCommand(serverCommand()))
+}
+
+func TestAnalyzeCommand_RetriesAreOptIn(t *testing.T) {
+	retries := func(args ...string) int {
+		cmd := analyzeCommand()
+		var n int
+		cmd.Action = func(c *cli.Context) error {
+			n = c.Int("retries")
+			return nil
+		}
+		app := &cli.App{Name: "psi-map", Commands: []*cli.Command{cmd}}
+		require.NoError(t, app.Run(append([]string{"psi-map", cmd.Name}, args...)))
+		return n
+	}
+
+	assert.Equal(t, 0, retries("sitemap.xml"))
+	assert.Equal(t, 3, retries("--retries", "3", "sitemap.xml"))
+}
@@ -3,7 +3,10 @@ package cli
 import (
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/mattjh1/psi-map/internal/logger"
+	"github.com/mattjh1/psi-map/internal/utils"
 	"github.com/urfave/cli/v2"
 )
 
@@ -20,17 +23,80 @@ func NewApp(version, commit, buildTime string) *cli.App {
 				Email: "me@mattjh.sh",
 			},
 		},
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "ci",
+				Usage: "Plain output for CI logs: no colors, spinners, progress bars or prompts, periodic progress lines instead (default: on when $CI is set or stderr is not a terminal)",
+			},
+			&cli.StringFlag{
+				Name:  "log-format",
+				Usage: "Log format: text, json (one record per line with level, tag, URL and duration; implies --ci)",
+				Value: logger.FormatText,
+			},
+			&cli.StringFlag{
+				Name:    "cache-backend",
+				Usage:   "Cache storage: file (one JSON file per URL), bolt (a single database file, faster for large caches)",
+				Value:   utils.CacheBackendFile,
+				EnvVars: []string{"PSI_MAP_CACHE_BACKEND"},
+			},
+		},
+		Before: func(c *cli.Context) error {
+			if err := configureLogging(c); err != nil {
+				return err
+			}
+			return utils.SetCacheBackend(strings.ToLower(c.String("cache-backend")))
+		},
+		After: func(c *cli.Context) error {
+			return utils.CloseCacheStores()
+		},
 		Commands: []*cli.Command{
 			analyzeCommand(),
 			serverCommand(),
+			mergeCommand(),
+			watchCommand(),
 			cacheCommands(),
+			sitemapCommands(),
 		},
 		ExitErrHandler: func(c *cli.Context, err error) {
 			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				if log := logger.GetLogger(); log.Format == logger.FormatJSON {
+					log.Error("%v", err)
+				} else {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				}
 				os.Exit(1)
 			}
 		},
 		UsageText: `psi-map [command] [options] [arguments...]`,
+		// Filter rules may contain commas (e.g. regex quantifiers), so never split them
+		DisableSliceFlagSeparator: true,
+	}
+}
+
+// configureLogging switches the logger to CI mode or JSON records before any
+// command runs
+func configureLogging(c *cli.Context) error {
+	format := strings.ToLower(c.String("log-format"))
+	switch format {
+	case logger.FormatText, logger.FormatJSON:
+	default:
+		return fmt.Errorf("unsupported log format: %s (supported: text, json)", format)
+	}
+	ci := detectCI()
+	if c.IsSet("ci") {
+		ci = c.Bool("ci")
+	}
+	logger.GetLogger().Configure(logger.WithCI(ci), logger.WithFormat(format))
+	return nil
+}
+
+// detectCI reports whether the logs go to a CI job rather than a terminal
+func detectCI() bool {
+	switch strings.ToLower(os.Getenv("CI")) {
+	case "", "0", "false":
+	default:
+		return true
 	}
+	info, err := os.Stderr.Stat()
+	return err != nil || info.Mode()&os.ModeCharDevice == 0
 }
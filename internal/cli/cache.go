@@ -23,7 +23,8 @@ Examples:
   psi-map cache list
   psi-map cache list --verbose
   psi-map cache clean --dry-run
-  psi-map cache clear --force`,
+  psi-map cache clear --force
+  psi-map cache migrate --to bolt`,
 		Subcommands: []*cli.Command{
 			{
 				Name:   "list",
@@ -70,6 +71,29 @@ Examples:
 					},
 				},
 			},
+			{
+				Name:  "migrate",
+				Usage: "Move the cache to another storage backend",
+				Description: `Copy every cached result and sitemap index from one backend to another,
+then remove them from the source unless --keep is given. Select the new
+backend afterwards with --cache-backend or $PSI_MAP_CACHE_BACKEND.`,
+				Action: cacheMigrateCommand,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "from",
+						Usage: "Backend to move the cache from (default: the selected --cache-backend)",
+					},
+					&cli.StringFlag{
+						Name:     "to",
+						Usage:    "Backend to move the cache to: file, bolt",
+						Required: true,
+					},
+					&cli.BoolFlag{
+						Name:  "keep",
+						Usage: "Keep the cache in the source backend too",
+					},
+				},
+			},
 		},
 	}
 }
@@ -171,6 +195,9 @@ func generateSitemapRow(info *types.CacheInfo, verbose bool) []string {
 func generateURLRow(urlInfo *types.URLCacheDetail, verbose bool) []string {
 	urlStatus := getStatusIcon(urlInfo)
 	url := truncateURL(urlInfo.URL, 70)
+	if urlInfo.Strategy != "" {
+		url = fmt.Sprintf("%s (%s)", truncateURL(urlInfo.URL, 60), urlInfo.Strategy)
+	}
 	scoreStr := ""
 	if urlInfo.PerformanceScore > 0 {
 		scoreStr = fmt.Sprintf("%d", int(math.Round(urlInfo.PerformanceScore)))
@@ -208,13 +235,13 @@ func cacheCleanCommand(c *cli.Context) error {
 	}
 
 	if cleanedCount == 0 {
-		l.Success("No expired cache files found")
+		l.Success("No expired cache entries found")
 	} else {
 		action := "would be removed"
 		if !dryRun {
 			action = "removed"
 		}
-		l.Success("Cache cleanup completed: %d expired file(s) %s", cleanedCount, action)
+		l.Success("Cache cleanup completed: %d expired cache entry(s) %s", cleanedCount, action)
 	}
 
 	return nil
@@ -260,6 +287,45 @@ func cacheClearCommand(c *cli.Context) error {
 	return nil
 }
 
+func cacheMigrateCommand(c *cli.Context) error {
+	l := logger.GetLogger()
+	u := l.UI(logger.WithUIStyle(&logger.UIStyle{
+		HeaderBgColor: pterm.BgCyan,
+	}))
+	u.Header("Cache Migrate")
+
+	from := strings.ToLower(c.String("from"))
+	if from == "" {
+		from = strings.ToLower(c.String("cache-backend"))
+	}
+	to := strings.ToLower(c.String("to"))
+	for _, backend := range []string{from, to} {
+		if err := utils.ValidateCacheBackend(backend); err != nil {
+			return err
+		}
+	}
+
+	l.Tagged("CACHE", "Migrating cache from %s to %s", "🚚", from, to)
+	entries, indexes, err := utils.MigrateCache(from, to)
+	if err != nil {
+		return fmt.Errorf("failed to migrate cache: %w", err)
+	}
+	l.Success("Migrated %d cached result(s) and %d sitemap index(es)", entries, indexes)
+
+	if !c.Bool("keep") {
+		store, err := utils.OpenCacheStore(from)
+		if err != nil {
+			return err
+		}
+		if _, err := store.Clear(); err != nil {
+			return fmt.Errorf("failed to clear the %s cache: %w", from, err)
+		}
+		l.Info("Removed the %s cache", from)
+	}
+	l.Info("Use --cache-backend %s or set PSI_MAP_CACHE_BACKEND=%s to use the migrated cache", to, to)
+	return nil
+}
+
 // Helper functions
 func getStatusIcon(detail *types.URLCacheDetail) string {
 	if detail.IsExpired {
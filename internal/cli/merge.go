@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mattjh1/psi-map/internal/constants"
+	"github.com/mattjh1/psi-map/internal/logger"
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/mattjh1/psi-map/internal/utils"
+	"github.com/urfave/cli/v2"
+)
+
+// mergeCommand returns the merge subcommand
+func mergeCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "merge",
+		Usage:     "Combine JSON reports from sharded runs into one report",
+		ArgsUsage: "<report.json...>",
+		Description: `Combine the JSON reports of analyze --shard jobs into one report with a single
+summary. The merged report can be written as JSON, HTML or CSV.
+
+Examples:
+  psi-map merge shard-1.json shard-2.json shard-3.json
+  psi-map merge -o html --name full-report reports/shard-*.json
+  psi-map merge -o csv reports/*.json`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Output format: json, html, csv, stdout (default: json)",
+				Value:   constants.JSON,
+			},
+			&cli.StringFlag{
+				Name:  "output-dir",
+				Usage: "Output directory (default: current directory)",
+				Value: ".",
+			},
+			&cli.StringFlag{
+				Name:  "name",
+				Usage: "Output filename (without extension, default: psi-report-merged)",
+				Value: "psi-report-merged",
+			},
+		},
+		Action: mergeReportsCommand,
+	}
+}
+
+func mergeReportsCommand(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return fmt.Errorf("at least one JSON report is required")
+	}
+	start := time.Now()
+	log := logger.GetLogger()
+
+	outputFile, outputFormat, useStdout, err := resolveOutput(c.String("output"), c.String("output-dir"), c.String("name"))
+	if err != nil {
+		return err
+	}
+
+	reports := make([]*types.ReportData, 0, c.NArg())
+	for _, path := range c.Args().Slice() {
+		report, err := utils.LoadReport(path)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, report)
+	}
+	merged, err := utils.MergeReports(reports)
+	if err != nil {
+		return err
+	}
+	log.Tagged("STEP", "Merged %d report(s) into %d page(s)", "🧩", len(reports), len(merged.Results))
+
+	config := &types.AnalysisConfig{
+		OutputFile:   outputFile,
+		OutputFormat: outputFormat,
+		UseStdout:    useStdout,
+	}
+	return handleOutput(config, merged, time.Since(start), nil)
+}
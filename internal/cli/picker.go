@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/mattjh1/psi-map/internal/logger"
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/mattjh1/psi-map/internal/utils"
+)
+
+// promptMu keeps the pickers of a multi-site run from prompting at once
+var promptMu sync.Mutex
+
+// checkInteractive fails early when there is no terminal to prompt on
+func checkInteractive() error {
+	if logger.GetLogger().CI {
+		return fmt.Errorf("--interactive cannot be used in CI mode")
+	}
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return fmt.Errorf("--interactive requires a terminal")
+	}
+	return nil
+}
+
+// pickEntries lets the user choose the sections, or pages within them, to
+// analyze and offers to save the choice as an include list
+func pickEntries(name string, entries []types.URL) ([]types.URL, error) {
+	promptMu.Lock()
+	defer promptMu.Unlock()
+
+	log := logger.GetLogger()
+	ui := log.UI()
+	if len(entries) == 0 {
+		return entries, nil
+	}
+
+	urls := make([]string, len(entries))
+	for i, e := range entries {
+		urls[i] = e.Loc
+	}
+	sections := utils.GroupURLSections(urls)
+	options := make([]string, len(sections))
+	bySection := make(map[string]types.URLSection, len(sections))
+	for i, s := range sections {
+		options[i] = fmt.Sprintf("%s (%d URLs)", s.Section, len(s.URLs))
+		bySection[options[i]] = s
+	}
+
+	question := "Select sections to analyze (type to search)"
+	if name != "" {
+		question = fmt.Sprintf("%s: %s", name, question)
+	}
+	answer, err := ui.Prompt(question, logger.MultiSelectInput, options...)
+	if err != nil {
+		return nil, fmt.Errorf("section prompt failed: %w", err)
+	}
+	chosen, _ := answer.([]string)
+	if len(chosen) == 0 {
+		return nil, fmt.Errorf("no sections selected")
+	}
+
+	var rules, candidates []string
+	for _, option := range chosen {
+		s := bySection[option]
+		rules = append(rules, utils.SectionIncludeRules(s.Section)...)
+		candidates = append(candidates, s.URLs...)
+	}
+
+	answer, err = ui.Prompt("Narrow down to individual pages?", logger.ConfirmInput)
+	if err != nil {
+		return nil, fmt.Errorf("page prompt failed: %w", err)
+	}
+	if narrow, _ := answer.(bool); narrow {
+		answer, err = ui.Prompt("Select pages to analyze (type to search)", logger.MultiSelectInput, candidates...)
+		if err != nil {
+			return nil, fmt.Errorf("page prompt failed: %w", err)
+		}
+		pages, _ := answer.([]string)
+		if len(pages) == 0 {
+			return nil, fmt.Errorf("no pages selected")
+		}
+		candidates = pages
+		rules = make([]string, len(pages))
+		for i, page := range pages {
+			rules[i] = utils.PageIncludeRule(page)
+		}
+	}
+
+	selected := make(map[string]bool, len(candidates))
+	for _, u := range candidates {
+		selected[u] = true
+	}
+	picked := make([]types.URL, 0, len(candidates))
+	for _, e := range entries {
+		if selected[e.Loc] {
+			picked = append(picked, e)
+		}
+	}
+	log.Tagged("FILTER", "Selected %d of %d URL(s)", "👆", len(picked), len(entries))
+
+	answer, err = ui.Prompt("Save selection as an include list? (file path, empty to skip)", logger.TextInput)
+	if err != nil {
+		return nil, fmt.Errorf("save prompt failed: %w", err)
+	}
+	if path, _ := answer.(string); strings.TrimSpace(path) != "" {
+		if saved, err := utils.SaveIncludeList(strings.TrimSpace(path), rules); err != nil {
+			log.Error("Failed to save include list: %v", err)
+		} else {
+			log.Success("Include list saved, reuse it with --include-file %s", saved)
+		}
+	}
+	return picked, nil
+}
@@ -0,0 +1,388 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/mattjh1/psi-map/internal/logger"
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/mattjh1/psi-map/internal/utils"
+	"github.com/mattjh1/psi-map/psimap"
+)
+
+// urlPipeline prepares URLs for analysis one entry at a time: hreflang
+// expansion, normalization, filtering, sampling, rewriting and cache lookup.
+// URLs that are not cached are handed to the runner as soon as they are ready.
+type urlPipeline struct {
+	site     string // site name in multi-site runs
+	config   *types.AnalysisConfig
+	expander *utils.AlternateExpander
+	deduper  *utils.URLDeduper
+	filter   *utils.URLFilter
+	rewriter *utils.URLRewriter
+	cache    *utils.URLCache
+	events   *psimap.EventBus
+
+	found   int
+	foreign int         // URLs left to other shards
+	entries []types.URL // unique entries, kept for hreflang grouping
+	urls    []string    // URLs selected for analysis, cached or not
+	cached  []*types.PageResult
+	sample  *types.SampleReport
+
+	trickleStats *types.TrickleReport
+	resumed      atomic.Int32 // URLs analyzed again for their failed strategies only
+
+	originals map[string]string // rewritten URL -> sitemap URL
+
+	// ready is closed once the runner may start, so interactive prompts are
+	// not drawn over its progress output
+	ready     chan struct{}
+	readyOnce sync.Once
+}
+
+// newURLPipeline validates the preparation settings and opens the cache
+func newURLPipeline(config *types.AnalysisConfig) (*urlPipeline, error) {
+	log := logger.GetLogger()
+
+	normalize := config.Normalize
+	if normalize == nil {
+		normalize = &types.NormalizeConfig{}
+	}
+	deduper, err := utils.NewURLDeduper(normalize)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := utils.NewURLFilter(config.Include, config.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	rewriter, err := utils.NewURLRewriter(config.Rewrite)
+	if err != nil {
+		return nil, err
+	}
+	if config.Sample != nil {
+		if err := utils.ValidateSampleConfig(config.Sample); err != nil {
+			return nil, err
+		}
+	}
+	if config.Trickle != nil {
+		if err := utils.ValidateTrickleConfig(config.Trickle); err != nil {
+			return nil, err
+		}
+	}
+
+	cache, err := utils.OpenURLCache(config.Sitemap, nil, config.CacheTTL)
+	if err != nil {
+		log.Warn("Cache check failed: %v", err)
+		log.Info("Continuing with full analysis")
+		cache = nil
+	} else {
+		cache.FailureTTL = config.FailureTTL
+	}
+
+	p := &urlPipeline{
+		config:    config,
+		deduper:   deduper,
+		filter:    filter,
+		rewriter:  rewriter,
+		cache:     cache,
+		originals: make(map[string]string),
+		ready:     make(chan struct{}),
+	}
+	if config.Hreflang {
+		p.expander = utils.NewAlternateExpander()
+	}
+	if !config.Interactive {
+		p.markReady()
+	}
+	return p, nil
+}
+
+// run reads every source entry and sends the URLs to analyze, closing analyze
+// when done. With sampling, trickle or the interactive picker, nothing is
+// sent until the source is exhausted. Once ctx is cancelled, nothing more is sent.
+func (p *urlPipeline) run(ctx context.Context, source <-chan types.URL, analyze chan<- string) error {
+	defer close(analyze)
+	defer p.markReady()
+
+	collect := p.config.Sample != nil || p.config.Interactive || p.config.Trickle != nil
+
+	var pending []types.URL
+	for entry := range source {
+		if ctx.Err() != nil {
+			return nil
+		}
+		expanded := []types.URL{entry}
+		if p.expander != nil {
+			expanded = p.expander.Expand(entry)
+		}
+
+		for _, e := range expanded {
+			p.found++
+			e, ok := p.deduper.Add(e)
+			if !ok {
+				continue
+			}
+			if p.expander != nil {
+				p.entries = append(p.entries, e)
+			}
+			if !p.filter.Keep(e.Loc) {
+				continue
+			}
+			if collect {
+				pending = append(pending, e)
+				continue
+			}
+			p.dispatch(ctx, e.Loc, analyze)
+		}
+	}
+
+	if !collect {
+		return nil
+	}
+
+	if p.config.Interactive {
+		picked, err := pickEntries(p.site, pending)
+		if err != nil {
+			return err
+		}
+		pending = picked
+	}
+	p.markReady()
+
+	urls := make([]string, 0, len(pending))
+	if p.config.Sample == nil {
+		for _, e := range pending {
+			urls = append(urls, e.Loc)
+		}
+	} else {
+		sampled, sample, err := utils.SampleURLs(pending, p.config.Sample)
+		if err != nil {
+			return err
+		}
+		urls = sampled
+		p.sample = sample
+		logger.GetLogger().Tagged("SAMPLE", "Sampled %d of %d URL(s) from %d cluster(s)", "🧪",
+			sample.SampledURLs, sample.TotalURLs, len(sample.Clusters))
+	}
+
+	if p.config.Trickle != nil {
+		p.trickle(ctx, urls, analyze)
+		return nil
+	}
+	for _, u := range urls {
+		p.dispatch(ctx, u, analyze)
+	}
+	return nil
+}
+
+// markReady lets the runner start
+func (p *urlPipeline) markReady() {
+	p.readyOnce.Do(func() { close(p.ready) })
+}
+
+// dispatch drops URLs of other shards, rewrites the URL, then serves it from
+// cache or sends it for analysis unless ctx was cancelled
+func (p *urlPipeline) dispatch(ctx context.Context, url string, analyze chan<- string) {
+	url, ok := p.prepare(url)
+	if !ok {
+		return
+	}
+	if result, ok := p.cache.Lookup(url); ok {
+		p.serveCached(url, result)
+		return
+	}
+	p.send(ctx, url, analyze)
+}
+
+// prepare drops URLs of other shards and rewrites the rest
+func (p *urlPipeline) prepare(url string) (string, bool) {
+	if !utils.InShard(url, p.config.Shard) {
+		p.foreign++
+		return "", false
+	}
+	if rewritten := p.rewriter.Rewrite(url); rewritten != url {
+		p.originals[rewritten] = url
+		url = rewritten
+	}
+	return url, true
+}
+
+// serveCached uses a cached result instead of analyzing the URL
+func (p *urlPipeline) serveCached(url string, result *types.PageResult) {
+	p.urls = append(p.urls, url)
+	p.cached = append(p.cached, result)
+	p.events.Emit(types.Event{Type: types.EventCacheHit, URL: url, Result: result})
+}
+
+// send hands the URL to the runner unless ctx was cancelled
+func (p *urlPipeline) send(ctx context.Context, url string, analyze chan<- string) {
+	select {
+	case analyze <- url:
+		p.urls = append(p.urls, url)
+	case <-ctx.Done():
+	}
+}
+
+// trickle analyzes at most the budget of URLs that are new or expired and
+// serves every other URL from cache, expired or not, so the report covers
+// the whole site as of its latest analyses
+func (p *urlPipeline) trickle(ctx context.Context, urls []string, analyze chan<- string) {
+	cfg := p.config.Trickle
+	stats := &types.TrickleReport{Budget: cfg.Budget, Order: cfg.Order}
+	if stats.Order == "" {
+		stats.Order = types.TrickleOldest
+	}
+
+	prepared := make([]string, 0, len(urls))
+	pages := make(map[string]*utils.CachedPage, len(urls))
+	var due []utils.TrickleCandidate
+	for _, url := range urls {
+		url, ok := p.prepare(url)
+		if !ok {
+			continue
+		}
+		prepared = append(prepared, url)
+		page, cached := p.cache.Page(url)
+		switch {
+		case !cached:
+			stats.New++
+			due = append(due, utils.TrickleCandidate{URL: url})
+		case p.cache.IsExpired(page):
+			stats.Expired++
+			due = append(due, utils.TrickleCandidate{URL: url, Analyzed: page.Analyzed, Change: page.Change})
+			pages[url] = page
+		default:
+			pages[url] = page
+		}
+	}
+
+	picked := utils.PlanTrickle(due, cfg)
+	stats.Total = len(prepared)
+	stats.Analyzed = len(picked)
+	stats.Pending = len(due) - len(picked)
+	p.trickleStats = stats
+	logger.GetLogger().Tagged("TRICKLE", "Analyzing %d of %d due URL(s) (%d new, %d expired), %d left for later runs", "💧",
+		stats.Analyzed, len(due), stats.New, stats.Expired, stats.Pending)
+
+	analyzing := make(map[string]bool, len(picked))
+	for _, url := range picked {
+		analyzing[url] = true
+	}
+	for _, url := range prepared {
+		if page, ok := pages[url]; ok && !analyzing[url] {
+			p.serveCached(url, page.Result)
+		}
+	}
+	for _, url := range picked {
+		p.send(ctx, url, analyze)
+	}
+}
+
+// resume returns the fresh strategies of a partially cached URL and the
+// strategies left to analyze
+func (p *urlPipeline) resume(url string) (*types.PageResult, []string) {
+	partial, missing := p.cache.Resume(url)
+	if partial != nil {
+		p.resumed.Add(1)
+	}
+	return partial, missing
+}
+
+// markRewritten records the sitemap URL on results for rewritten URLs
+func (p *urlPipeline) markRewritten(results []*types.PageResult) {
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		if original, ok := p.originals[result.URL]; ok {
+			result.OriginalURL = original
+		}
+	}
+	if len(p.originals) > 0 {
+		logger.GetLogger().Tagged("REWRITE", "Rewrote %d URL(s) before analysis", "🔀", len(p.originals))
+	}
+}
+
+// dedupeStats returns the normalization stats when anything was merged or rewritten
+func (p *urlPipeline) dedupeStats() *types.DedupeStats {
+	stats := p.deduper.Stats()
+	if stats.Merged == 0 && stats.Rewritten == 0 {
+		return nil
+	}
+	return stats
+}
+
+// filterStats returns the filter stats when any rules were given
+func (p *urlPipeline) filterStats() *types.FilterStats {
+	if p.filter.IsEmpty() {
+		return nil
+	}
+	return p.filter.Stats()
+}
+
+// streamEntries returns the URL entries of the configured input source as
+// they are discovered, until ctx is cancelled
+func streamEntries(ctx context.Context, config *types.AnalysisConfig) (<-chan types.URL, <-chan error) {
+	out := make(chan types.URL)
+	errc := make(chan error, 1)
+	send := func(entry types.URL) bool {
+		select {
+		case out <- entry:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	go func() {
+		defer close(errc)
+		defer close(out)
+
+		if config.Crawl != nil {
+			urls, err := utils.Crawl(ctx, config.Crawl)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				errc <- fmt.Errorf("failed to crawl site: %w", err)
+				return
+			}
+			for _, u := range urls {
+				if !send(types.URL{Loc: u}) {
+					return
+				}
+			}
+			return
+		}
+
+		if config.FromDir != nil {
+			urls, err := utils.StaticDirURLs(config.FromDir)
+			if err != nil {
+				errc <- fmt.Errorf("failed to read static site: %w", err)
+				return
+			}
+			for _, u := range urls {
+				if !send(types.URL{Loc: u}) {
+					return
+				}
+			}
+			return
+		}
+
+		entries, parseErrc := psimap.StreamSitemap(ctx, config.Sitemap)
+		for entry := range entries {
+			if !send(entry) {
+				// ctx is done, so the parser stops as well
+				return
+			}
+		}
+		if err := <-parseErrc; err != nil && ctx.Err() == nil {
+			errc <- fmt.Errorf("failed to parse input: %w", err)
+		}
+	}()
+	return out, errc
+}
@@ -1,8 +1,13 @@
 package cli
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/mattjh1/psi-map/internal/constants"
@@ -11,125 +16,389 @@ import (
 	"github.com/mattjh1/psi-map/internal/types"
 	"github.com/mattjh1/psi-map/internal/utils"
 	"github.com/mattjh1/psi-map/internal/utils/validate"
-	"github.com/mattjh1/psi-map/runner"
+	"github.com/mattjh1/psi-map/psimap"
 	"github.com/urfave/cli/v2"
 )
 
 // runAnalysis executes the core analysis logic
 func runAnalysis(c *cli.Context, isServerCommand bool) error {
-	format := strings.ToLower(c.String("output"))
-	outputDir := c.String("output-dir")
-	name := c.String("name")
-
-	var outputFile string
+	var outputFile, outputFormat string
 	var useStdout bool
-	var outputFormat string
-
-	if format == "stdout" {
-		useStdout = true
-		outputFormat = constants.JSON // Default format for stdout
-	} else if !isServerCommand {
-		// Set the output format to match the requested format
-		outputFormat = format
-
-		// Validate and construct output file path securely
-		var extension string
-		switch format {
-		case constants.JSON:
-			extension = ".json"
-		case constants.HTML:
-			extension = ".html"
-		default:
-			return fmt.Errorf("unsupported output format: %s", format)
-		}
-
-		// Use secure path validation
-		validatedPath, err := validate.ValidateOutputPath(outputDir, name, extension)
+	if !isServerCommand {
+		var err error
+		outputFile, outputFormat, useStdout, err = resolveOutput(c.String("output"), c.String("output-dir"), c.String("name"))
 		if err != nil {
-			return fmt.Errorf("invalid output path: %w", err)
+			return err
 		}
-		outputFile = validatedPath
 	}
 
-	// Validate sitemap input path if it's a file path
-	sitemapInput := c.Args().First()
-	if sitemapInput != "" && !strings.HasPrefix(sitemapInput, "http") {
-		// It's a file path, validate it
-		validatedSitemap, err := validate.ValidateInputPath(sitemapInput)
+	config, err := analysisConfig(c)
+	if err != nil {
+		return err
+	}
+	config.OutputFile = outputFile
+	config.OutputFormat = outputFormat
+	config.UseStdout = useStdout
+	config.StartServer = isServerCommand
+	return executeAnalysis(config)
+}
+
+// analysisConfig builds the inputs and URL preparation settings from the
+// flags. Output settings are left to the caller.
+func analysisConfig(c *cli.Context) (*types.AnalysisConfig, error) {
+	sites, err := collectSites(c)
+	if err != nil {
+		return nil, err
+	}
+	var shard *types.ShardConfig
+	if value := c.String("shard"); value != "" {
+		if shard, err = utils.ParseShard(value); err != nil {
+			return nil, err
+		}
+	}
+	include := c.StringSlice("include")
+	if includeFile := c.String("include-file"); includeFile != "" {
+		rules, err := utils.LoadIncludeList(includeFile)
 		if err != nil {
-			return fmt.Errorf("invalid sitemap path: %w", err)
+			return nil, err
+		}
+		include = append(include, rules...)
+	}
+	if c.Bool("interactive") {
+		if err := checkInteractive(); err != nil {
+			return nil, err
 		}
-		sitemapInput = validatedSitemap
+	}
+
+	var sitemapInput string
+	if len(sites) == 1 {
+		sitemapInput = sites[0].Sitemap
+	}
+
+	workers := c.Int("workers")
+	if c.Bool("adaptive") && !c.IsSet("workers") {
+		workers = constants.AdaptiveMaxWorkers
 	}
 
 	config := &types.AnalysisConfig{
-		Sitemap:      sitemapInput,
-		OutputFile:   outputFile,
-		OutputFormat: outputFormat,
-		UseStdout:    useStdout,
-		StartServer:  isServerCommand,
-		ServerPort:   c.String("port"),
-		MaxWorkers:   c.Int("workers"),
-		CacheTTL:     c.Int("cache-ttl"),
+		Sitemap:     sitemapInput,
+		ServerPort:  c.String("port"),
+		MaxWorkers:  workers,
+		Adaptive:    c.Bool("adaptive"),
+		Retries:     c.Int("retries"),
+		CacheTTL:    c.Int("cache-ttl"),
+		FailureTTL:  c.Duration("failure-ttl"),
+		Include:     include,
+		Exclude:     c.StringSlice("exclude"),
+		Hreflang:    c.Bool("hreflang"),
+		Interactive: c.Bool("interactive"),
+		EventsFile:  c.String("events"),
+		Shard:       shard,
+
+		Preflight:            c.Bool("preflight"),
+		PreflightConcurrency: c.Int("preflight-concurrency"),
+		Normalize: &types.NormalizeConfig{
+			TrailingSlash: strings.ToLower(c.String("trailing-slash")),
+			LowercaseHost: !c.Bool("keep-host-case"),
+			DropFragment:  !c.Bool("keep-fragment"),
+			DropParams:    c.StringSlice("drop-param"),
+		},
 	}
-	return executeAnalysis(config)
+
+	if len(sites) > 1 {
+		utils.NameSites(sites)
+		config.Sites = sites
+	}
+
+	rewrite, err := rewriteConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	config.Rewrite = rewrite
+
+	if perCluster := c.Int("sample"); perCluster > 0 {
+		config.Sample = &types.SampleConfig{
+			PerCluster: perCluster,
+			Mode:       strings.ToLower(c.String("sample-mode")),
+			Seed:       c.Int64("sample-seed"),
+		}
+	}
+
+	if budget := c.Int("trickle"); budget > 0 {
+		config.Trickle = &types.TrickleConfig{
+			Budget: budget,
+			Order:  strings.ToLower(c.String("trickle-order")),
+		}
+	}
+
+	if startURL := c.String("crawl"); startURL != "" {
+		config.Sitemap = startURL
+		config.Crawl = &types.CrawlConfig{
+			StartURL:     startURL,
+			MaxDepth:     c.Int("crawl-depth"),
+			MaxPages:     c.Int("crawl-max-pages"),
+			Concurrency:  c.Int("crawl-concurrency"),
+			Include:      c.StringSlice("crawl-include"),
+			Exclude:      c.StringSlice("crawl-exclude"),
+			IgnoreRobots: c.Bool("crawl-ignore-robots"),
+		}
+	}
+	if dir := c.String("from-dir"); dir != "" {
+		// Results are cached per deployment URL
+		config.Sitemap = c.String("base-url")
+		config.FromDir = &types.StaticDirConfig{
+			Dir:       dir,
+			BaseURL:   c.String("base-url"),
+			CleanURLs: c.Bool("clean-urls"),
+		}
+	}
+	return config, nil
+}
+
+// resolveOutput validates the output format and builds the report path
+func resolveOutput(format, outputDir, name string) (outputFile, outputFormat string, useStdout bool, err error) {
+	format = strings.ToLower(format)
+	if format == constants.STDOUT {
+		return "", constants.JSON, true, nil // Default format for stdout
+	}
+
+	// Validate and construct output file path securely
+	var extension string
+	switch format {
+	case constants.JSON:
+		extension = ".json"
+	case constants.HTML:
+		extension = ".html"
+	case constants.CSV:
+		extension = ".csv"
+	default:
+		return "", "", false, fmt.Errorf("unsupported output format: %s", format)
+	}
+
+	// Use secure path validation
+	validatedPath, err := validate.ValidateOutputPath(outputDir, name, extension)
+	if err != nil {
+		return "", "", false, fmt.Errorf("invalid output path: %w", err)
+	}
+	return validatedPath, format, false, nil
+}
+
+// collectSites returns the sitemap inputs given as arguments and in the
+// manifest, with local file paths validated
+func collectSites(c *cli.Context) ([]types.Site, error) {
+	sites := make([]types.Site, 0, c.NArg())
+	for _, input := range c.Args().Slice() {
+		sites = append(sites, types.Site{Sitemap: input})
+	}
+	if manifest := c.String("manifest"); manifest != "" {
+		validatedManifest, err := validate.ValidateInputPath(manifest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid manifest path: %w", err)
+		}
+		listed, err := utils.ParseSiteManifest(validatedManifest)
+		if err != nil {
+			return nil, err
+		}
+		if len(listed) == 0 {
+			return nil, fmt.Errorf("manifest %s lists no sites", manifest)
+		}
+		sites = append(sites, listed...)
+	}
+
+	for i := range sites {
+		// Validate sitemap input path if it's a file path
+		if !strings.HasPrefix(sites[i].Sitemap, "http") {
+			validatedSitemap, err := validate.ValidateInputPath(sites[i].Sitemap)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sitemap path: %w", err)
+			}
+			sites[i].Sitemap = validatedSitemap
+		}
+	}
+	return sites, nil
 }
 
-// executeAnalysis runs the analysis with the given configuration
+// executeAnalysis runs the analysis with the given configuration and
+// outputs the report
 func executeAnalysis(config *types.AnalysisConfig) error {
-	log := logger.GetLogger()
 	start := time.Now()
 
-	// Parse input to get URLs first (needed for URL-level cache check)
-	urls, err := utils.ParseSitemap(config.Sitemap)
+	events := psimap.NewEventBus()
+	closeLog, err := subscribeEventLog(config, events)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+	var live *server.Server
+	if config.StartServer {
+		if live, err = server.StartLive(config.ServerPort); err != nil {
+			return fmt.Errorf("failed to start server: %w", err)
+		}
+		events.Subscribe(live.Publish)
+	}
+	// Ctrl+C stops the run but keeps what finished; a second one exits at once
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	// Restore the default handling as soon as the first signal arrives
+	context.AfterFunc(ctx, stop)
+	report, interrupted, err := analyzeOnce(ctx, config, events)
+	stop()
 	if err != nil {
-		return fmt.Errorf("failed to parse input: %w", err)
+		return err
 	}
+	return finishReport(config, report, interrupted, start, live)
+}
 
-	log.Info("Found %d URLs to analyze", len(urls))
+// analyzeOnce runs one analysis of every input, reporting to events, and
+// builds the report. URLs are streamed from every input and analyzed as soon
+// as they are prepared. It reports whether ctx stopped the run early.
+func analyzeOnce(ctx context.Context, config *types.AnalysisConfig, events *psimap.EventBus) (*types.ReportData, bool, error) {
+	log := logger.GetLogger()
 
-	// Check URL-level cache
-	cachedResults, missingURLs, err := utils.CheckURLCache(config.Sitemap, urls, config.CacheTTL)
+	sites := config.Sites
+	if len(sites) == 0 {
+		sites = []types.Site{{Sitemap: config.Sitemap}}
+	}
+	runs := make([]*siteRun, 0, len(sites))
+	for _, site := range sites {
+		run, err := newSiteRun(config, site, events)
+		if err != nil {
+			return nil, false, err
+		}
+		runs = append(runs, run)
+	}
+	owners := newURLOwners()
+	analyzer, err := psimap.NewAnalyzer(psimap.Options{
+		Workers:  config.MaxWorkers,
+		Adaptive: config.Adaptive,
+		Retries:  config.Retries,
+		Events:   events,
+		Progress: true,
+		// Pages cached with only some strategies failed re-fetch just those
+		Resume: func(url string) (*types.PageResult, []string) {
+			return runs[owners.peek(url)].pipeline.resume(url)
+		},
+	})
 	if err != nil {
-		log.Warn("Cache check failed: %v", err)
-		log.Info("Continuing with full analysis")
-		// Continue with full analysis if cache check fails
-		missingURLs = urls
-		cachedResults = nil
+		return nil, false, err
+	}
+	if len(runs) > 1 {
+		log.Tagged("ANALYZE", "Analyzing %d sites with %d shared worker(s)", "🗂️", len(runs), config.MaxWorkers)
 	}
 
-	// Report cache status
-	cachedCount := len(cachedResults)
-	missingCount := len(missingURLs)
+	// Each result is cached as soon as it finishes, so a crash or a killed
+	// CI job loses at most the requests in flight
+	unsubscribe := events.Subscribe(func(e types.Event) {
+		if e.Type == types.EventCompleted || e.Type == types.EventFailed {
+			runs[owners.take(e.URL)].store(e.Result)
+		}
+	})
+	defer unsubscribe()
+	for _, run := range runs {
+		run.start(ctx)
+	}
 
-	if cachedCount > 0 {
-		log.Tagged("CACHE", "Found %d cached result(s), %d URL(s) need analysis", "🎯", cachedCount, missingCount)
-	} else {
-		log.Tagged("CACHE", "No cached results found, analyzing all %d URLs", "📊", missingCount)
+	// Wait for any interactive pickers before the runner draws its progress
+	for _, run := range runs {
+		<-run.pipeline.ready
 	}
 
-	var newResults []*types.PageResult
+	// Keep unreachable or non-HTML URLs from reaching PSI
+	toRun := mergeSiteURLs(runs, owners)
+	var preflight *utils.Preflight
+	if config.Preflight {
+		preflight = utils.NewPreflight(config.PreflightConcurrency)
+		toRun = preflight.Stream(toRun)
+	}
+
+	_, runErr := analyzer.AnalyzeStream(ctx, toRun)
+	interrupted := errors.Is(runErr, context.Canceled)
+
+	siteErrs := make([]error, len(runs))
+	for i, run := range runs {
+		siteErrs[i] = run.wait()
+		run.flush()
+	}
+	skipped := make([][]types.SkippedURL, len(runs))
+	if preflight != nil {
+		skipped = splitSkipped(runs, owners, preflight.Skipped())
+	}
 
-	// Only analyze missing URLs
-	if missingCount > 0 {
-		log.Tagged("ANALYZE", "Starting analysis of %d URL(s)...", "🔍", missingCount)
-		newResults = runner.RunBatch(missingURLs, config.MaxWorkers)
+	if len(runs) == 1 {
+		if siteErrs[0] != nil {
+			return nil, interrupted, siteErrs[0]
+		}
+		site, results := runs[0].report(skipped[0])
+		report := psimap.NewReport(results)
+		report.Dedupe = site.Dedupe
+		report.Filter = site.Filter
+		report.Skipped = site.Skipped
+		report.Locales = site.Locales
+		report.Sample = site.Sample
+		report.Trickle = site.Trickle
+		return report, interrupted, nil
+	}
 
-		// Save new results to cache
-		if err := utils.SaveURLCache(config.Sitemap, urls, newResults); err != nil {
-			log.Error("Failed to save cache: %v", err)
-			log.Info("Continuing...")
-		} else {
-			log.Tagged("CACHE", "%d new result(s) cached successfully", "💾", len(newResults))
+	// A site whose input failed keeps whatever results it got
+	var allResults []*types.PageResult
+	siteReports := make([]types.SiteReport, 0, len(runs))
+	for i, run := range runs {
+		log.UI().Section(run.site.Name)
+		site, results := run.report(skipped[i])
+		if siteErrs[i] != nil {
+			log.Error("%s: %v", run.site.Name, siteErrs[i])
+			site.Error = siteErrs[i].Error()
+		}
+		for _, result := range results {
+			result.Site = run.site.Name
 		}
+		allResults = append(allResults, results...)
+		siteReports = append(siteReports, *site)
 	}
 
-	// Combine cached and new results
-	allResults := combineResults(cachedResults, newResults)
-	elapsed := time.Since(start)
+	report := psimap.NewReport(allResults)
+	report.Sites = siteReports
+	utils.PrintPortfolio(siteReports)
+	return report, interrupted, nil
+}
 
-	// Handle output based on configuration
-	return handleOutput(config, allResults, elapsed)
+// subscribeEventLog writes the events to the --events file when one is set.
+// It returns the function that closes the log.
+func subscribeEventLog(config *types.AnalysisConfig, events *psimap.EventBus) (func(), error) {
+	if config.EventsFile == "" {
+		return func() {}, nil
+	}
+	eventLog, err := utils.OpenEventLog(config.EventsFile)
+	if err != nil {
+		return nil, err
+	}
+	events.Subscribe(eventLog.Write)
+	logger.GetLogger().Info("Writing run events to %s", eventLog.Path())
+	return func() { closeEventLog(eventLog) }, nil
+}
+
+// finishReport outputs the report, marking it incomplete when the run was
+// interrupted. An interrupted run still fails once the report is written.
+func finishReport(config *types.AnalysisConfig, report *types.ReportData, interrupted bool, start time.Time, live *server.Server) error {
+	report.Shard = config.Shard
+	if interrupted {
+		report.Incomplete = true
+		logger.GetLogger().Warn("Run was interrupted, the report only covers the %d page(s) finished so far", len(report.Results))
+	}
+	if err := handleOutput(config, report, time.Since(start), live); err != nil {
+		return err
+	}
+	if interrupted {
+		return fmt.Errorf("analysis interrupted, partial report written")
+	}
+	return nil
+}
+
+// closeEventLog reports event log write errors without failing the run
+func closeEventLog(eventLog *utils.EventLog) {
+	if err := eventLog.Close(); err != nil {
+		logger.GetLogger().Warn("%v", err)
+	}
 }
 
 // combineResults merges cached and new results, maintaining URL order from sitemap
@@ -163,18 +432,20 @@ func combineResults(cached, fresh []*types.PageResult) []*types.PageResult {
 	return combined
 }
 
-// handleOutput processes the results based on the configuration
-func handleOutput(config *types.AnalysisConfig, results []*types.PageResult, elapsed time.Duration) error {
+// handleOutput processes the results based on the configuration. In server
+// mode the live server started before the run is handed the final report.
+func handleOutput(config *types.AnalysisConfig, report *types.ReportData, elapsed time.Duration, live *server.Server) error {
 	log := logger.GetLogger()
 
 	switch {
-	case config.StartServer:
-		if err := server.Start(results, config.ServerPort); err != nil {
-			return fmt.Errorf("failed to start server: %w", err)
+	case live != nil:
+		live.Finish(report)
+		if err := live.Wait(); err != nil {
+			return fmt.Errorf("server error: %w", err)
 		}
 	case config.UseStdout:
 		log.Tagged("STEP", "Outputting results to stdout", "📤")
-		if err := utils.SaveJSONToStdout(results); err != nil {
+		if err := utils.SaveJSONToStdout(report); err != nil {
 			return fmt.Errorf("failed to output JSON to stdout: %w", err)
 		}
 	case config.OutputFile != "":
@@ -182,21 +453,27 @@ func handleOutput(config *types.AnalysisConfig, results []*types.PageResult, ela
 		switch config.OutputFormat {
 		case "html":
 			log.Tagged("STEP", "Generating HTML report: %s", "📄", config.OutputFile)
-			if err := utils.SaveHTMLReport(results, config.OutputFile); err != nil {
+			if err := utils.SaveHTMLReport(report, config.OutputFile); err != nil {
 				return fmt.Errorf("failed to generate HTML report: %w", err)
 			}
 			log.Success("HTML report saved: %s", config.OutputFile)
 		case "json":
 			log.Tagged("STEP", "Generating JSON report: %s", "📋", config.OutputFile)
-			if err := utils.SaveJSONReport(results, config.OutputFile); err != nil {
+			if err := utils.SaveJSONReport(report, config.OutputFile); err != nil {
 				return fmt.Errorf("failed to generate JSON report: %w", err)
 			}
 			log.Success("JSON report saved: %s", config.OutputFile)
+		case constants.CSV:
+			log.Tagged("STEP", "Generating CSV report: %s", "📊", config.OutputFile)
+			if err := utils.SaveCSVReport(report, config.OutputFile); err != nil {
+				return fmt.Errorf("failed to generate CSV report: %w", err)
+			}
+			log.Success("CSV report saved: %s", config.OutputFile)
 		}
-		utils.PrintSummary(results, elapsed)
+		utils.PrintSummary(report.Results, elapsed)
 	default:
 		// Just print summary to console
-		utils.PrintSummary(results, elapsed)
+		utils.PrintSummary(report.Results, elapsed)
 	}
 	return nil
 }
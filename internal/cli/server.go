@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"fmt"
 	"runtime"
 
 	"github.com/mattjh1/psi-map/internal/constants"
@@ -16,14 +15,16 @@ func serverCommand() *cli.Command {
 		Name:      "server",
 		Aliases:   []string{"serve"},
 		Usage:     "Start interactive web server for analysis",
-		ArgsUsage: "[flags] <sitemap_url_or_file>",
+		ArgsUsage: "[flags] <sitemap_url_or_file...>",
 		Description: `Start a web server to interactively analyze and view PageSpeed Insights results.
         
 Examples:
   psi-map server sitemap.xml
   psi-map serve --port 3000 https://example.com/sitemap.xml
-  psi-map serve --port 8080 sitemap.xml`,
-		Flags: []cli.Flag{
+  psi-map serve --port 8080 sitemap.xml
+  psi-map serve --manifest sites.txt
+  psi-map serve --crawl https://example.com --sample 3`,
+		Flags: append([]cli.Flag{
 			&cli.StringFlag{
 				Name:    "port",
 				Aliases: []string{"p"},
@@ -41,12 +42,11 @@ Examples:
 				Value: constants.DefaultTTLHours,
 				Usage: "Cache TTL in hours (0 = no expiration)",
 			},
-		},
+		}, append(sourceFlags(), sharedFlags()...)...),
 		Action: func(c *cli.Context) error {
-			if c.NArg() < 1 {
-				return fmt.Errorf("sitemap URL or file path is required")
+			if err := validateInputSource(c); err != nil {
+				return err
 			}
-
 			return runAnalysis(c, true)
 		},
 	}
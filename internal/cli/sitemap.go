@@ -0,0 +1,187 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/mattjh1/psi-map/internal/constants"
+	"github.com/mattjh1/psi-map/internal/logger"
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/mattjh1/psi-map/internal/utils"
+	"github.com/mattjh1/psi-map/internal/utils/validate"
+	"github.com/pterm/pterm"
+	"github.com/urfave/cli/v2"
+)
+
+// Output formats of `sitemap inspect`
+const (
+	inspectTable = "table"
+	inspectJSON  = "json"
+)
+
+func sitemapCommands() *cli.Command {
+	return &cli.Command{
+		Name:  "sitemap",
+		Usage: "Work with sitemaps without calling PageSpeed Insights",
+		Description: `Check sitemap health before spending PSI quota on it.
+        
+Examples:
+  psi-map sitemap inspect sitemap.xml
+  psi-map sitemap inspect --check-status https://example.com/sitemap.xml
+  psi-map sitemap inspect -o json sitemap.xml`,
+		Subcommands: []*cli.Command{
+			{
+				Name:      "inspect",
+				Usage:     "Validate a sitemap and summarize its URLs",
+				ArgsUsage: "<sitemap_url_or_file>",
+				Action:    sitemapInspectCommand,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "Output format: table, json",
+						Value:   inspectTable,
+					},
+					&cli.BoolFlag{
+						Name:  "check-status",
+						Usage: "Request every URL and flag non-200 responses and redirects",
+					},
+					&cli.IntFlag{
+						Name:  "concurrency",
+						Usage: "Maximum number of concurrent status checks",
+						Value: constants.DefaultStatusConcurrency,
+					},
+				},
+			},
+		},
+	}
+}
+
+func sitemapInspectCommand(c *cli.Context) error {
+	if c.NArg() < 1 {
+		return fmt.Errorf("sitemap URL or file path is required")
+	}
+	format := strings.ToLower(c.String("output"))
+	if format != inspectTable && format != inspectJSON {
+		return fmt.Errorf("unsupported output format: %s (supported: table, json)", format)
+	}
+
+	input := c.Args().First()
+	if !strings.HasPrefix(input, "http") {
+		validated, err := validate.ValidateInputPath(input)
+		if err != nil {
+			return fmt.Errorf("invalid sitemap path: %w", err)
+		}
+		input = validated
+	}
+
+	inspection, err := utils.InspectSitemap(input, &types.InspectOptions{
+		CheckStatus: c.Bool("check-status"),
+		Concurrency: c.Int("concurrency"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to inspect sitemap: %w", err)
+	}
+
+	if format == inspectJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(inspection); err != nil {
+			return fmt.Errorf("failed to encode inspection: %w", err)
+		}
+		return nil
+	}
+
+	printInspection(inspection)
+	return nil
+}
+
+// printInspection renders the inspection as tables
+func printInspection(inspection *types.SitemapInspection) {
+	l := logger.GetLogger()
+	u := l.UI(logger.WithUIStyle(&logger.UIStyle{
+		TableBorderStyle: pterm.NewStyle(pterm.FgLightBlue),
+		HeaderBgColor:    pterm.BgBlue,
+	}))
+
+	u.Header("Sitemap Inspection")
+	l.Tagged("INSPECT", "%d URL(s) in %d sitemap(s), %d valid, domain %s", "🗺️",
+		inspection.TotalURLs, len(inspection.Sitemaps), inspection.ValidURLs, inspection.Domain)
+
+	u.Section("Issues")
+	if len(inspection.Issues) == 0 {
+		l.Success("No schema or URL problems found")
+	} else {
+		data := make([][]string, 0, len(inspection.Issues))
+		for _, issue := range inspection.Issues {
+			data = append(data, []string{
+				truncateURL(issue.Sitemap, 40),
+				strconv.Itoa(issue.Line),
+				truncateURL(issue.URL, 50),
+				issue.Message,
+			})
+		}
+		u.Table([]string{"SITEMAP", "LINE", "URL", "PROBLEM"}, data)
+	}
+
+	if len(inspection.Duplicates) > 0 {
+		u.Section("Duplicates")
+		data := make([][]string, 0, len(inspection.Duplicates))
+		for _, dup := range inspection.Duplicates {
+			data = append(data, []string{truncateURL(dup.URL, 80), strconv.Itoa(dup.Count)})
+		}
+		u.Table([]string{"URL", "COUNT"}, data)
+	}
+
+	if len(inspection.OffDomain) > 0 {
+		u.Section("Off-domain URLs")
+		data := make([][]string, 0, len(inspection.OffDomain))
+		for _, offDomain := range inspection.OffDomain {
+			data = append(data, []string{truncateURL(offDomain, 90)})
+		}
+		u.Table([]string{"URL"}, data)
+	}
+
+	if len(inspection.Sections) > 0 {
+		u.Section("URLs per Section")
+		data := make([][]string, 0, len(inspection.Sections))
+		for _, section := range inspection.Sections {
+			data = append(data, []string{section.Section, strconv.Itoa(section.Count)})
+		}
+		u.Table([]string{"SECTION", "URLS"}, data)
+	}
+
+	u.Section("Lastmod")
+	lastmod := inspection.Lastmod
+	data := make([][]string, 0, len(lastmod.Buckets)+2)
+	for _, bucket := range lastmod.Buckets {
+		data = append(data, []string{bucket.Label, strconv.Itoa(bucket.Count)})
+	}
+	data = append(data, []string{"missing", strconv.Itoa(lastmod.Missing)}, []string{"invalid", strconv.Itoa(lastmod.Invalid)})
+	u.Table([]string{"AGE", "URLS"}, data)
+	if lastmod.Oldest != "" {
+		l.Info("Oldest %s, newest %s", lastmod.Oldest, lastmod.Newest)
+	}
+
+	if status := inspection.Status; status != nil {
+		u.Section("Status Checks")
+		l.Tagged("INSPECT", "%d checked: %d OK, %d redirect(s), %d error(s)", "",
+			status.Checked, status.OK, status.Redirects, status.Errors)
+		if len(status.Problems) > 0 {
+			data := make([][]string, 0, len(status.Problems))
+			for _, problem := range status.Problems {
+				result := strconv.Itoa(problem.StatusCode)
+				if problem.Error != "" {
+					result = problem.Error
+				} else if problem.Location != "" {
+					result += " → " + truncateURL(problem.Location, 50)
+				}
+				data = append(data, []string{truncateURL(problem.URL, 70), result})
+			}
+			u.Table([]string{"URL", "STATUS"}, data)
+		}
+	}
+}
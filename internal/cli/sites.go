@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"context"
+	"sync"
+
+	"github.com/mattjh1/psi-map/internal/logger"
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/mattjh1/psi-map/internal/utils"
+	"github.com/mattjh1/psi-map/psimap"
+)
+
+// siteRun streams and prepares the URLs of one input of the run. All sites
+// feed the same runner, so they share its worker pool.
+type siteRun struct {
+	site     types.Site
+	config   *types.AnalysisConfig
+	pipeline *urlPipeline
+
+	analyze      chan string
+	sourceErrc   <-chan error
+	pipelineErrc chan error
+
+	mu    sync.Mutex
+	fresh []*types.PageResult // results analyzed in this run
+	saved int                 // fresh results written to the cache
+}
+
+// newSiteRun validates the settings for a site without reading its input.
+// Cache hits are reported on events.
+func newSiteRun(config *types.AnalysisConfig, site types.Site, events *psimap.EventBus) (*siteRun, error) {
+	siteConfig := *config
+	siteConfig.Sitemap = site.Sitemap
+	siteConfig.Sites = nil
+
+	pipeline, err := newURLPipeline(&siteConfig)
+	if err != nil {
+		return nil, err
+	}
+	pipeline.site = site.Name
+	pipeline.events = events
+	return &siteRun{
+		site:         site,
+		config:       &siteConfig,
+		pipeline:     pipeline,
+		analyze:      make(chan string),
+		pipelineErrc: make(chan error, 1),
+	}, nil
+}
+
+// start streams the site's input through its pipeline until ctx is cancelled
+func (r *siteRun) start(ctx context.Context) {
+	logger.GetLogger().Tagged("ANALYZE", "Streaming URLs from %s", "🔍", r.config.Sitemap)
+	source, sourceErrc := streamEntries(ctx, r.config)
+	r.sourceErrc = sourceErrc
+	go func() {
+		r.pipelineErrc <- r.pipeline.run(ctx, source, r.analyze)
+	}()
+}
+
+// wait returns the error that stopped the site's input, if any
+func (r *siteRun) wait() error {
+	pipelineErr := <-r.pipelineErrc
+	if sourceErr := <-r.sourceErrc; sourceErr != nil {
+		return sourceErr
+	}
+	return pipelineErr
+}
+
+// store keeps a finished result and writes it to the site's cache at once
+func (r *siteRun) store(result *types.PageResult) {
+	r.mu.Lock()
+	r.fresh = append(r.fresh, result)
+	r.mu.Unlock()
+
+	if r.pipeline.cache == nil {
+		return
+	}
+	if err := r.pipeline.cache.Save(result); err != nil {
+		logger.GetLogger().Error("Failed to cache %s: %v", result.URL, err)
+		return
+	}
+	r.mu.Lock()
+	r.saved++
+	r.mu.Unlock()
+}
+
+// flush writes the site's cache index once every result is in
+func (r *siteRun) flush() {
+	if err := r.pipeline.cache.Flush(); err != nil {
+		logger.GetLogger().Error("Failed to update the cache index of %s: %v", r.config.Sitemap, err)
+	}
+}
+
+// report logs how the site's URLs were prepared and collects its results
+func (r *siteRun) report(skipped []types.SkippedURL) (*types.SiteReport, []*types.PageResult) {
+	log := logger.GetLogger()
+	p := r.pipeline
+
+	log.Info("Found %d URLs to analyze", p.found)
+	if shard := r.config.Shard; shard != nil {
+		log.Info("Shard %d/%d: %d URL(s) selected, %d left to other shards", shard.Index, shard.Count, len(p.urls), p.foreign)
+	}
+	if r.saved > 0 {
+		log.Tagged("CACHE", "%d new result(s) cached as they finished", "💾", r.saved)
+	}
+	if n := p.resumed.Load(); n > 0 {
+		log.Tagged("CACHE", "Re-analyzed only the failed strategy of %d partially cached URL(s)", "🩹", n)
+	}
+	site := &types.SiteReport{
+		Name:    r.site.Name,
+		Sitemap: r.config.Sitemap,
+		Dedupe:  p.dedupeStats(),
+		Filter:  p.filterStats(),
+		Skipped: skipped,
+		Trickle: p.trickleStats,
+	}
+	if site.Dedupe != nil {
+		utils.PrintDedupeStats(site.Dedupe)
+	}
+	if site.Filter != nil {
+		utils.PrintFilterStats(site.Filter)
+	}
+	if len(p.cached) > 0 {
+		log.Tagged("CACHE", "Found %d cached result(s), analyzed %d URL(s)", "🎯", len(p.cached), len(r.fresh))
+	} else {
+		log.Tagged("CACHE", "No cached results found, analyzed all %d URLs", "📊", len(r.fresh))
+	}
+
+	// Combine cached and new results
+	results := combineResults(p.cached, r.fresh)
+	p.markRewritten(results)
+
+	if len(skipped) > 0 {
+		utils.PrintSkippedURLs(skipped)
+	}
+	if r.config.Hreflang {
+		if localeGroups := utils.BuildLocaleGroups(p.entries); len(localeGroups) > 0 {
+			utils.CompareLocales(localeGroups, results)
+			utils.PrintLocaleReport(localeGroups)
+			site.Locales = localeGroups
+		}
+	}
+	if p.sample != nil {
+		utils.ExtrapolateSample(p.sample, results)
+		utils.PrintSampleReport(p.sample)
+		site.Sample = p.sample
+	}
+
+	site.Summary = psimap.Summarize(results)
+	return site, results
+}
+
+// urlOwners records which site sent each URL to the runner. A URL listed by
+// two sites is sent twice and handed to each once, in order.
+type urlOwners struct {
+	mu    sync.Mutex
+	sites map[string][]int
+}
+
+func newURLOwners() *urlOwners {
+	return &urlOwners{sites: make(map[string][]int)}
+}
+
+func (o *urlOwners) add(url string, site int) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.sites[url] = append(o.sites[url], site)
+}
+
+// take returns the next site waiting on the URL, or the first site when the
+// URL is unknown
+func (o *urlOwners) take(url string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	queue := o.sites[url]
+	if len(queue) == 0 {
+		return 0
+	}
+	o.sites[url] = queue[1:]
+	return queue[0]
+}
+
+// peek returns the next site waiting on the URL without taking it, or the
+// first site when the URL is unknown
+func (o *urlOwners) peek(url string) int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if queue := o.sites[url]; len(queue) > 0 {
+		return queue[0]
+	}
+	return 0
+}
+
+// mergeSiteURLs fans the URLs of every site into one channel for the runner,
+// recording which site sent each
+func mergeSiteURLs(runs []*siteRun, owners *urlOwners) <-chan string {
+	out := make(chan string)
+	var wg sync.WaitGroup
+	for i, run := range runs {
+		wg.Add(1)
+		go func(i int, in <-chan string) {
+			defer wg.Done()
+			for url := range in {
+				owners.add(url, i)
+				out <- url
+			}
+		}(i, run.analyze)
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// splitSkipped groups the URLs skipped by the preflight check by site
+func splitSkipped(runs []*siteRun, owners *urlOwners, skipped []types.SkippedURL) [][]types.SkippedURL {
+	bySite := make([][]types.SkippedURL, len(runs))
+	for _, s := range skipped {
+		i := owners.take(s.URL)
+		bySite[i] = append(bySite[i], s)
+	}
+	return bySite
+}
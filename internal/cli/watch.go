@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+
+	"github.com/mattjh1/psi-map/internal/constants"
+	"github.com/mattjh1/psi-map/internal/logger"
+	"github.com/mattjh1/psi-map/internal/server"
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/mattjh1/psi-map/internal/utils"
+	"github.com/mattjh1/psi-map/internal/utils/validate"
+	"github.com/mattjh1/psi-map/psimap"
+	"github.com/urfave/cli/v2"
+)
+
+// historyTimeFormat stamps the report of each watch run
+const historyTimeFormat = "20060102-150405"
+
+// watchCommand returns the watch subcommand
+func watchCommand() *cli.Command {
+	defaultWorkers := max(1, runtime.NumCPU()/constants.CPUDivisor)
+
+	return &cli.Command{
+		Name:      "watch",
+		Usage:     "Re-analyze a sitemap on a schedule and keep every run's report",
+		ArgsUsage: "[flags] <sitemap_url_or_file... | --manifest file | --crawl start_url | --from-dir dir --base-url url>",
+		Description: `Run as a long-lived process that analyzes right away, then again on a schedule.
+Every run's report is saved as timestamped JSON in the history directory.
+Pages analyzed within --cache-ttl are reused from the cache, so keep the TTL
+shorter than the schedule to re-analyze every page each run.
+
+Examples:
+  psi-map watch --every 6h sitemap.xml
+  psi-map watch --cron "0 3 * * *" --cache-ttl 12 https://example.com/sitemap.xml
+  psi-map watch --every 24h --serve --port 3000 sitemap.xml
+  psi-map watch --every 12h --history-dir ./history --manifest sites.txt`,
+		Flags: append([]cli.Flag{
+			&cli.DurationFlag{
+				Name:  "every",
+				Usage: "Re-analyze at this interval, e.g. 30m, 6h",
+			},
+			&cli.StringFlag{
+				Name:  "cron",
+				Usage: "Re-analyze on a five-field cron schedule in local time instead, e.g. \"0 3 * * *\"",
+			},
+			&cli.StringFlag{
+				Name:  "history-dir",
+				Usage: "Directory that keeps the JSON report of every run",
+				Value: "psi-history",
+			},
+			&cli.StringFlag{
+				Name:  "name",
+				Usage: "Report filename prefix, the run's start time is appended",
+				Value: "psi-report",
+			},
+			&cli.BoolFlag{
+				Name:  "serve",
+				Usage: "Serve the live dashboard while watching, showing the latest run",
+			},
+			&cli.StringFlag{
+				Name:    "port",
+				Aliases: []string{"p"},
+				Usage:   "Server port for --serve",
+				Value:   "8080",
+			},
+			&cli.IntFlag{
+				Name:    "workers",
+				Aliases: []string{"w"},
+				Usage:   "Maximum number of concurrent workers",
+				Value:   defaultWorkers,
+			},
+			&cli.IntFlag{
+				Name:  "cache-ttl",
+				Value: constants.DefaultTTLHours,
+				Usage: "Cache TTL in hours (0 = no expiration)",
+			},
+		}, append(sourceFlags(), sharedFlags()...)...),
+		Action: runWatch,
+	}
+}
+
+// watcher re-runs one analysis configuration on a schedule
+type watcher struct {
+	config     *types.AnalysisConfig
+	schedule   utils.Schedule
+	historyDir string
+	name       string
+	events     *psimap.EventBus
+	live       *server.Server // nil unless --serve
+}
+
+func runWatch(c *cli.Context) error {
+	if err := validateInputSource(c); err != nil {
+		return err
+	}
+	schedule, err := utils.ParseSchedule(c.Duration("every"), c.String("cron"))
+	if err != nil {
+		return err
+	}
+	// Fail before the first run rather than after it
+	if _, err := validate.ValidateOutputPath(c.String("history-dir"), c.String("name"), ".json"); err != nil {
+		return fmt.Errorf("invalid history path: %w", err)
+	}
+	config, err := analysisConfig(c)
+	if err != nil {
+		return err
+	}
+
+	w := &watcher{
+		config:     config,
+		schedule:   schedule,
+		historyDir: c.String("history-dir"),
+		name:       c.String("name"),
+		events:     psimap.NewEventBus(),
+	}
+	// One event log covers every run
+	closeLog, err := subscribeEventLog(config, w.events)
+	if err != nil {
+		return err
+	}
+	defer closeLog()
+	if c.Bool("serve") {
+		if w.live, err = server.StartLive(c.String("port")); err != nil {
+			return fmt.Errorf("failed to start server: %w", err)
+		}
+		defer func() {
+			if err := w.live.Close(); err != nil {
+				logger.GetLogger().Warn("%v", err)
+			}
+		}()
+		w.events.Subscribe(w.live.Publish)
+	}
+
+	// Ctrl+C stops the current run, saves what finished and stops watching
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	return w.loop(ctx)
+}
+
+// loop runs right away and then at every scheduled time until ctx is done.
+// A failed run is logged and the watch goes on.
+func (w *watcher) loop(ctx context.Context) error {
+	log := logger.GetLogger()
+	w.warnStaleCache(time.Now())
+
+	for run := 1; ; run++ {
+		start := time.Now()
+		log.Tagged("WATCH", "Starting run %d", "👀", run)
+		if err := w.run(ctx, start); err != nil {
+			log.Error("Run %d failed: %v", run, err)
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		next := w.schedule.Next(start)
+		if next.IsZero() {
+			return fmt.Errorf("schedule has no upcoming run")
+		}
+		if now := time.Now(); next.Before(now) {
+			// The run outlasted the interval, start the next one now
+			log.Warn("Run %d took longer than the schedule allows, starting the next run now", run)
+			next = now
+		}
+		log.Tagged("WATCH", "Next run at %s", "⏰", next.Format(time.DateTime))
+		select {
+		case <-time.After(time.Until(next)):
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	log.Tagged("WATCH", "Stopped watching", "🛑")
+	return nil
+}
+
+// run analyzes once, saves the report to the history directory and hands it
+// to the dashboard
+func (w *watcher) run(ctx context.Context, start time.Time) error {
+	log := logger.GetLogger()
+	if w.live != nil {
+		w.live.Begin()
+	}
+	report, interrupted, err := analyzeOnce(ctx, w.config, w.events)
+	if err != nil {
+		return err
+	}
+	if interrupted {
+		report.Incomplete = true
+		log.Warn("Run was interrupted, the report only covers the %d page(s) finished so far", len(report.Results))
+	}
+
+	path := filepath.Join(w.historyDir, w.name+"-"+start.Format(historyTimeFormat)+".json")
+	if err := utils.SaveJSONReport(report, path); err != nil {
+		return fmt.Errorf("failed to save run history: %w", err)
+	}
+	log.Success("Run report saved: %s", path)
+	if w.live != nil {
+		w.live.Finish(report)
+	}
+	utils.PrintSummary(report.Results, time.Since(start))
+	return nil
+}
+
+// warnStaleCache points out when cached results outlive the gap between
+// runs, since those pages are not re-analyzed until their entry expires
+func (w *watcher) warnStaleCache(now time.Time) {
+	log := logger.GetLogger()
+	first := w.schedule.Next(now)
+	gap := w.schedule.Next(first).Sub(first)
+	ttl := time.Duration(w.config.CacheTTL) * time.Hour
+	switch {
+	case ttl == 0:
+		log.Warn("Cached results never expire with --cache-ttl 0, so later runs only analyze new pages")
+	case ttl >= gap:
+		log.Warn("Cached results are reused for %s, longer than the %s between runs; lower --cache-ttl to re-analyze every run", ttl, gap)
+	}
+}
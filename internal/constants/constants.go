@@ -6,18 +6,88 @@ import "time"
 const (
 	HTML   = "html"
 	JSON   = "json"
+	CSV    = "csv"
 	STDOUT = "stdout"
 )
 
 // Runner constants
 const (
-	WaitGroupWorkers = 2
+	// PSIRetryBackoff times the attempt number is the pause before each
+	// retry of a rate-limited or failed strategy
+	PSIRetryBackoff = 5 * time.Second
+
+	// Adaptive concurrency starts at AdaptiveStartWorkers and grows by one
+	// per window of healthy requests, up to the worker count or
+	// AdaptiveMaxWorkers when none is given
+	AdaptiveStartWorkers = 2
+	AdaptiveMaxWorkers   = 16
+
+	// AdaptiveLatencyTolerance is how many times slower than its fastest
+	// smoothed latency PSI may get before concurrency stops growing
+	AdaptiveLatencyTolerance = 2.0
+	AdaptiveLatencySmoothing = 0.2
+
+	// AdaptiveMinCooldown is the least time between two decreases, so
+	// requests already in flight do not halve the limit again
+	AdaptiveMinCooldown = 5 * time.Second
 )
 
 // CLI App constants
 const (
 	CPUDivisor      = 2
 	DefaultTTLHours = 24
+
+	// DefaultFailureTTL is how long a failed strategy stays cached before
+	// it is analyzed again
+	DefaultFailureTTL = time.Hour
+
+	// CIProgressInterval is how often CI mode logs the progress of a run
+	CIProgressInterval = 15 * time.Second
+)
+
+// Cache storage constants
+const (
+	// CacheDBFile is the database of the bolt backend in the cache directory
+	CacheDBFile = "cache.db"
+
+	// CacheLockTimeout is how long to wait for another process to release
+	// the cache database
+	CacheLockTimeout = 5 * time.Second
+
+	// CacheMigrateBatch is how many entries are written per transaction
+	// when migrating a cache
+	CacheMigrateBatch = 1000
+
+	// CacheIndexFlushEvery is how many URLs join a sitemap index before it
+	// is written in the middle of a run
+	CacheIndexFlushEvery = 500
+)
+
+// Crawler constants
+const (
+	DefaultCrawlDepth       = 2
+	DefaultCrawlMaxPages    = 100
+	DefaultCrawlConcurrency = 4
+	CrawlRequestTimeout     = 15 * time.Second
+	CrawlMaxBodyBytes       = 5 << 20
+	CrawlUserAgent          = "psi-map"
+)
+
+// Sitemap parsing constants
+const (
+	SitemapFetchTimeout  = 60 * time.Second
+	SitemapStreamBuffer  = 64
+	MaxSitemapIndexDepth = 3
+)
+
+// Sitemap inspection constants
+const (
+	// Limits from the sitemaps.org protocol
+	MaxURLsPerSitemap = 50000
+	MaxLocLength      = 2048
+
+	DefaultStatusConcurrency = 8
+	StatusCheckTimeout       = 15 * time.Second
 )
 
 // CLI Cache constants
@@ -69,13 +139,18 @@ const (
 	// Context Timeout
 	ShutdownTimeout = 5 * time.Second
 
+	// EventStreamBuffer is how many events a slow event stream client may
+	// fall behind before events are dropped for it
+	EventStreamBuffer = 256
+
 	// Map allocation optimization
 	MapSizeDivisor = 2
 )
 
 // File System Permissions
 const (
-	DefaultDirPermissions = 0o755
+	DefaultDirPermissions  = 0o755
+	DefaultFilePermissions = 0o600
 )
 
 // Time Calculations
@@ -89,3 +164,21 @@ const (
 	AuditScoreGoodThreshold = 0.9
 	ScoreMultiplier         = 100
 )
+
+// URL sampling constants
+const (
+	// ClusterVariantThreshold is the number of distinct values at a path
+	// position above which that position is treated as a variable segment
+	ClusterVariantThreshold = 5
+	DefaultSamplePriority   = 0.5
+	DefaultSampleSeed       = 1
+)
+
+// Hreflang constants
+const (
+	HreflangXDefault = "x-default"
+
+	// LocaleGapWarnThreshold is the performance score gap between the locales
+	// of one page that is reported as a warning
+	LocaleGapWarnThreshold = 10
+)
@@ -1,13 +1,16 @@
 package logger
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/mattjh1/psi-map/internal/constants"
 	"github.com/pterm/pterm"
 )
 
@@ -21,6 +24,12 @@ const (
 	ERROR
 )
 
+// Log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
 // Logger is the main logger struct
 type Logger struct {
 	Level    LogLevel
@@ -28,6 +37,34 @@ type Logger struct {
 	ShowTime bool
 	Output   io.Writer
 	mu       sync.RWMutex
+	// writeMu serializes writes to Output, which callers share under mu's
+	// read lock
+	writeMu sync.Mutex
+
+	// CI replaces spinners, progress bars and prompts with plain log lines
+	CI bool
+	// Format is FormatText (default) or FormatJSON for one record per line
+	Format string
+}
+
+// Fields are structured values added to JSON log records
+type Fields struct {
+	URL      string
+	Duration time.Duration
+}
+
+// record is one JSON log line
+type record struct {
+	Time       time.Time  `json:"time"`
+	Level      string     `json:"level"`
+	Tag        string     `json:"tag,omitempty"`
+	Message    string     `json:"msg"`
+	URL        string     `json:"url,omitempty"`
+	DurationMS int64      `json:"duration_ms,omitempty"`
+	Done       *int       `json:"done,omitempty"`
+	Total      int        `json:"total,omitempty"`
+	Columns    []string   `json:"columns,omitempty"`
+	Rows       [][]string `json:"rows,omitempty"`
 }
 
 // UI provides methods for rendering CLI UI elements
@@ -45,6 +82,9 @@ type UIStyle struct {
 // InputType defines the type of interactive prompt
 type InputType int
 
+// multiSelectHeight is the number of options a multi-select prompt shows at once
+const multiSelectHeight = 15
+
 const (
 	TextInput InputType = iota
 	ConfirmInput
@@ -82,6 +122,27 @@ func WithPlainText(plain bool) Option {
 	}
 }
 
+// WithCI turns off colors, spinners, progress bars, prompts and screen
+// clearing, for logs that are not read on a terminal
+func WithCI(ci bool) Option {
+	return func(l *Logger) {
+		l.CI = ci
+		if ci {
+			pterm.DisableColor()
+		}
+	}
+}
+
+// WithFormat selects text or JSON log records. JSON implies CI mode.
+func WithFormat(format string) Option {
+	return func(l *Logger) {
+		l.Format = format
+		if format == FormatJSON {
+			WithCI(true)(l)
+		}
+	}
+}
+
 // WithOutput sets an alternative output destination for logs
 func WithOutput(w io.Writer) Option {
 	return func(l *Logger) { l.Output = w }
@@ -139,6 +200,16 @@ func New(options ...Option) *Logger {
 	return l
 }
 
+// Configure applies options to a logger that is already in use, e.g. once
+// command-line flags are parsed
+func (l *Logger) Configure(options ...Option) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, opt := range options {
+		opt(l)
+	}
+}
+
 // SetLevel safely updates the log level
 func (l *Logger) SetLevel(level LogLevel) {
 	l.mu.Lock()
@@ -167,7 +238,13 @@ func (l *Logger) log(level LogLevel, printer *pterm.PrefixPrinter, message strin
 		return
 	}
 	msg := fmt.Sprintf(message, args...)
+	if l.Format == FormatJSON {
+		l.writeRecord(record{Level: levelName(level), Message: l.formatMessage(msg)})
+		return
+	}
 	formattedMsg := l.formatMessage(msg)
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
 
 	// Output to CLI using pterm
 	printer.WithWriter(l.Output).Println(formattedMsg)
@@ -178,6 +255,32 @@ func (l *Logger) log(level LogLevel, printer *pterm.PrefixPrinter, message strin
 	}
 }
 
+// writeRecord writes one JSON log line. The caller holds the read lock.
+func (l *Logger) writeRecord(rec record) {
+	out := l.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	rec.Time = time.Now()
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
+	_ = json.NewEncoder(out).Encode(rec)
+}
+
+// levelName names a level in JSON records
+func levelName(level LogLevel) string {
+	switch level {
+	case DEBUG:
+		return "debug"
+	case WARN:
+		return "warn"
+	case ERROR:
+		return "error"
+	default:
+		return "info"
+	}
+}
+
 // Debug logs debug information (gray, low priority)
 func (l *Logger) Debug(message string, args ...any) {
 	l.log(DEBUG, &pterm.Debug, message, args...)
@@ -210,12 +313,27 @@ func (l *Logger) Success(message string, args ...any) {
 
 // Tagged logs messages with a custom tag and optional emoji
 func (l *Logger) Tagged(tag, message, emoji string, args ...any) {
+	l.TaggedFields(tag, Fields{}, message, emoji, args...)
+}
+
+// TaggedFields logs like Tagged and adds the fields to JSON records
+func (l *Logger) TaggedFields(tag string, fields Fields, message, emoji string, args ...any) {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
 	if l.Level > INFO {
 		return
 	}
 	msg := fmt.Sprintf(message, args...)
+	if l.Format == FormatJSON {
+		l.writeRecord(record{
+			Level:      levelName(INFO),
+			Tag:        tag,
+			Message:    l.formatMessage(msg),
+			URL:        fields.URL,
+			DurationMS: fields.Duration.Milliseconds(),
+		})
+		return
+	}
 	if emoji != "" {
 		msg = emoji + " " + msg
 	}
@@ -229,6 +347,8 @@ func (l *Logger) Tagged(tag, message, emoji string, args ...any) {
 		},
 		Writer: l.Output,
 	}
+	l.writeMu.Lock()
+	defer l.writeMu.Unlock()
 	printer.Println(formattedMsg)
 
 	// Output to alternative destination if specified
@@ -246,10 +366,28 @@ func tagColor(tag string) pterm.Color {
 		return pterm.BgMagenta
 	case "ANALYZE":
 		return pterm.BgGreen
-	case "PSI":
+	case "PSI", "ADAPTIVE":
 		return pterm.BgYellow
 	case "STEP":
 		return pterm.BgLightBlue
+	case "CRAWL":
+		return pterm.BgLightMagenta
+	case "FILTER":
+		return pterm.BgLightYellow
+	case "SAMPLE":
+		return pterm.BgLightGreen
+	case "DEDUPE":
+		return pterm.BgLightCyan
+	case "HREFLANG":
+		return pterm.BgLightRed
+	case "INSPECT":
+		return pterm.BgGray
+	case "PREFLIGHT":
+		return pterm.BgRed
+	case "REWRITE":
+		return pterm.BgDarkGray
+	case "WATCH":
+		return pterm.BgBlack
 	default:
 		return pterm.BgCyan
 	}
@@ -269,8 +407,30 @@ func (l *Logger) UI(options ...UIOption) *UI {
 	return ui
 }
 
+// modes returns whether the logger is in CI mode and writes JSON
+func (l *Logger) modes() (ci, jsonFormat bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.CI, l.Format == FormatJSON
+}
+
+// emit writes a JSON record at info level unless the level filters it out
+func (l *Logger) emit(rec record) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.Level > INFO {
+		return
+	}
+	rec.Level = levelName(INFO)
+	l.writeRecord(rec)
+}
+
 // Header prints a styled header
 func (u *UI) Header(title string) {
+	if _, jsonFormat := u.Logger.modes(); jsonFormat {
+		u.Logger.emit(record{Message: title})
+		return
+	}
 	header := pterm.DefaultHeader.WithFullWidth().WithWriter(u.Logger.Output)
 	if u.Style != nil && u.Style.HeaderBgColor != 0 {
 		header = header.WithBackgroundStyle(pterm.NewStyle(u.Style.HeaderBgColor))
@@ -282,12 +442,20 @@ func (u *UI) Header(title string) {
 
 // Section prints a section header with styling
 func (u *UI) Section(title string) {
+	if _, jsonFormat := u.Logger.modes(); jsonFormat {
+		u.Logger.emit(record{Message: title})
+		return
+	}
 	section := pterm.DefaultSection.WithLevel(2).WithWriter(u.Logger.Output)
 	section.Println(title)
 }
 
 // Table prints data in a table format
 func (u *UI) Table(headers []string, data [][]string) {
+	if _, jsonFormat := u.Logger.modes(); jsonFormat {
+		u.Logger.emit(record{Message: "table", Columns: headers, Rows: data})
+		return
+	}
 	table := pterm.DefaultTable.WithHasHeader().WithData(append(pterm.TableData{headers}, data...)).WithWriter(u.Logger.Output)
 	if u.Style != nil && u.Style.TableBorderStyle != nil {
 		table = table.WithBoxed(true).WithStyle(u.Style.TableBorderStyle)
@@ -300,6 +468,15 @@ func (u *UI) Table(headers []string, data [][]string) {
 
 // RunSpinner runs a spinner for a task
 func (u *UI) RunSpinner(text string, task func() error) error {
+	if ci, _ := u.Logger.modes(); ci {
+		u.Logger.Info("%s", text)
+		if err := task(); err != nil {
+			u.Logger.Error("Failed: %v", err)
+			return err
+		}
+		u.Logger.Success("Completed")
+		return nil
+	}
 	spinner, _ := pterm.DefaultSpinner.WithText(text).WithWriter(u.Logger.Output).Start()
 	err := task()
 	if err != nil {
@@ -310,8 +487,39 @@ func (u *UI) RunSpinner(text string, task func() error) error {
 	return nil
 }
 
+// RunCounter runs a spinner that counts completed steps, for tasks whose total
+// is not known up front
+func (u *UI) RunCounter(text string, task func(increment func()) error) error {
+	if ci, _ := u.Logger.modes(); ci {
+		return u.runPlainProgress(text, 0, task)
+	}
+	spinner, _ := pterm.DefaultSpinner.WithText(text).WithWriter(u.Logger.Output).Start()
+
+	var mu sync.Mutex
+	done := 0
+	increment := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		spinner.UpdateText(fmt.Sprintf("%s (%d done)", text, done))
+	}
+
+	err := task(increment)
+	if err != nil {
+		spinner.Fail("Failed: " + err.Error())
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	spinner.Success(fmt.Sprintf("Completed %d", done))
+	return nil
+}
+
 // RunProgressBar runs a progress bar for a task with a known total number of steps
 func (u *UI) RunProgressBar(text string, total int, task func(increment func()) error) error {
+	if ci, _ := u.Logger.modes(); ci {
+		return u.runPlainProgress(text, total, task)
+	}
 	// Initialize progress bar
 	progressbar, err := pterm.DefaultProgressbar.
 		WithTotal(total).
@@ -351,11 +559,58 @@ func (u *UI) RunProgressBar(text string, total int, task func(increment func())
 	return nil
 }
 
+// runPlainProgress runs a task in CI mode, logging its progress every
+// constants.CIProgressInterval and once it is done instead of redrawing
+func (u *UI) runPlainProgress(text string, total int, task func(increment func()) error) error {
+	var done atomic.Int64
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(constants.CIProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				u.progress(text, int(done.Load()), total)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	err := task(func() { done.Add(1) })
+	close(stop)
+	<-stopped
+	if err != nil {
+		u.Logger.Error("%s failed: %v", text, err)
+		return err
+	}
+	u.progress(text+" completed", int(done.Load()), total)
+	return nil
+}
+
+// progress logs one progress line of a task
+func (u *UI) progress(text string, done, total int) {
+	if _, jsonFormat := u.Logger.modes(); jsonFormat {
+		u.Logger.emit(record{Message: text, Done: &done, Total: total})
+		return
+	}
+	if total > 0 {
+		u.Logger.Info("%s: %d/%d (%d%%)", text, done, total, done*100/total)
+		return
+	}
+	u.Logger.Info("%s: %d done", text, done)
+}
+
 // Prompt creates an interactive prompt based on the input type
 func (u *UI) Prompt(question string, inputType InputType, options ...string) (any, error) {
 	if question == "" {
 		return nil, fmt.Errorf("prompt question cannot be empty")
 	}
+	if ci, _ := u.Logger.modes(); ci {
+		return nil, fmt.Errorf("cannot prompt %q in CI mode", question)
+	}
 	switch inputType {
 	case TextInput:
 		return pterm.DefaultInteractiveTextInput.Show(question)
@@ -370,7 +625,7 @@ func (u *UI) Prompt(question string, inputType InputType, options ...string) (an
 		if len(options) == 0 {
 			return nil, fmt.Errorf("multi-select input requires at least one option")
 		}
-		return pterm.DefaultInteractiveMultiselect.WithOptions(options).Show(question)
+		return pterm.DefaultInteractiveMultiselect.WithOptions(options).WithMaxHeight(multiSelectHeight).Show(question)
 	default:
 		return nil, fmt.Errorf("unknown input type: %v", inputType)
 	}
@@ -378,5 +633,8 @@ func (u *UI) Prompt(question string, inputType InputType, options ...string) (an
 
 // Clear clears the terminal screen
 func (u *UI) Clear() {
+	if ci, _ := u.Logger.modes(); ci {
+		return
+	}
 	fmt.Fprint(u.Logger.Output, "\033[H\033[2J")
 }
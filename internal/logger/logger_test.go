@@ -2,6 +2,7 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -184,6 +185,38 @@ func TestRunSpinner(t *testing.T) {
 	}
 }
 
+func TestRunCounter(t *testing.T) {
+	var buf threadSafeBuffer
+	l := New(WithLevel(INFO), WithOutput(&buf))
+	u := l.UI()
+
+	var wg sync.WaitGroup
+	err := u.RunCounter("Counting", func(increment func()) error {
+		for range 5 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				increment()
+			}()
+		}
+		wg.Wait()
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Completed 5") {
+		t.Errorf("Expected completed count, got: %v", buf.String())
+	}
+
+	err = u.RunCounter("Failing", func(increment func()) error {
+		return fmt.Errorf("task failed")
+	})
+	if err == nil || err.Error() != "task failed" {
+		t.Errorf("Expected error 'task failed', got: %v", err)
+	}
+}
+
 func TestPrompt(t *testing.T) {
 	l := New()
 	u := l.UI()
@@ -254,6 +287,33 @@ func TestThreadSafety(t *testing.T) {
 	}
 }
 
+func TestConcurrentWritesToPlainWriter(t *testing.T) {
+	// A plain bytes.Buffer is not safe for concurrent use; the logger
+	// serializes writes itself
+	for _, format := range []string{FormatText, FormatJSON} {
+		var buf bytes.Buffer
+		l := New(WithLevel(INFO), WithOutput(&buf), WithFormat(format))
+		var wg sync.WaitGroup
+		for i := range 20 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				l.TaggedFields("ANALYZE", Fields{URL: "https://example.com/"}, "Processed %d", "", i)
+				l.Info("Info %d", i)
+			}()
+		}
+		wg.Wait()
+
+		if format == FormatJSON {
+			for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+				if !json.Valid([]byte(line)) {
+					t.Errorf("Interleaved JSON record: %q", line)
+				}
+			}
+		}
+	}
+}
+
 func TestTimeFormat(t *testing.T) {
 	var buf threadSafeBuffer
 	l := New(WithLevel(INFO), WithTime(true), WithOutput(&buf))
@@ -266,3 +326,63 @@ func TestTimeFormat(t *testing.T) {
 		t.Errorf("Expected timestamp in output, got: %v", output)
 	}
 }
+
+func TestJSONFormat(t *testing.T) {
+	var buf threadSafeBuffer
+	l := New(WithLevel(INFO), WithOutput(&buf), WithFormat(FormatJSON))
+
+	l.Warn("Low score on %s", "https://example.com/")
+	l.TaggedFields("ANALYZE", Fields{URL: "https://example.com/", Duration: 1500 * time.Millisecond}, "Processed URL: %s", "", "https://example.com/")
+	l.UI().Table([]string{"URL", "Score"}, [][]string{{"https://example.com/", "42"}})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 records, got %d: %v", len(lines), buf.String())
+	}
+	var warn, tagged, table map[string]any
+	for i, target := range []*map[string]any{&warn, &tagged, &table} {
+		if err := json.Unmarshal([]byte(lines[i]), target); err != nil {
+			t.Fatalf("Record %d is not JSON: %v", i, err)
+		}
+	}
+	if warn["level"] != "warn" || warn["msg"] != "Low score on https://example.com/" {
+		t.Errorf("Unexpected warn record: %v", warn)
+	}
+	if tagged["tag"] != "ANALYZE" || tagged["url"] != "https://example.com/" || tagged["duration_ms"] != 1500.0 {
+		t.Errorf("Unexpected tagged record: %v", tagged)
+	}
+	if table["columns"] == nil || table["rows"] == nil {
+		t.Errorf("Expected table columns and rows, got: %v", table)
+	}
+	if !l.CI {
+		t.Errorf("Expected JSON format to imply CI mode")
+	}
+}
+
+func TestCIMode(t *testing.T) {
+	var buf threadSafeBuffer
+	l := New(WithLevel(INFO), WithOutput(&buf))
+	l.Configure(WithCI(true))
+	u := l.UI()
+
+	err := u.RunProgressBar("Processing URLs", 4, func(increment func()) error {
+		for range 4 {
+			increment()
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Expected no error, got: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Processing URLs completed: 4/4 (100%)") {
+		t.Errorf("Expected plain progress line, got: %v", buf.String())
+	}
+
+	u.Clear()
+	if strings.Contains(buf.String(), "\033[H\033[2J") {
+		t.Errorf("Expected no clear sequence in CI mode")
+	}
+	if _, err := u.Prompt("Continue?", ConfirmInput); err == nil {
+		t.Errorf("Expected prompts to fail in CI mode")
+	}
+}
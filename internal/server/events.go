@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mattjh1/psi-map/internal/constants"
+	"github.com/mattjh1/psi-map/internal/logger"
+	"github.com/mattjh1/psi-map/internal/types"
+)
+
+// eventHub broadcasts run events to the connected event stream clients
+type eventHub struct {
+	mu      sync.Mutex
+	clients map[chan types.Event]struct{}
+	closed  bool
+}
+
+func newEventHub() *eventHub {
+	return &eventHub{clients: make(map[chan types.Event]struct{})}
+}
+
+// subscribe returns a channel of events and a function that removes it. The
+// channel is closed when the hub shuts down.
+func (h *eventHub) subscribe() (<-chan types.Event, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ch := make(chan types.Event, constants.EventStreamBuffer)
+	if h.closed {
+		close(ch)
+		return ch, func() {}
+	}
+	h.clients[ch] = struct{}{}
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.clients[ch]; ok {
+			delete(h.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// broadcast sends the event to every client. A client that has fallen too far
+// behind misses the event rather than holding up the run.
+func (h *eventHub) broadcast(e types.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.clients {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// close ends every event stream
+func (h *eventHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for ch := range h.clients {
+		delete(h.clients, ch)
+		close(ch)
+	}
+}
+
+// handleEvents streams run events as server-sent events, named after the
+// event type, until the client disconnects or the server shuts down
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	log := logger.GetLogger()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	// The stream outlives the server's write timeout
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+		log.Warn("Could not lift write deadline for event stream: %v", err)
+	}
+
+	events, unsubscribe := s.events.subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(e)
+			if err != nil {
+				log.Error("Failed to encode event: %v", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/mattjh1/psi-map/internal/types"
+)
+
+func TestHandleEvents_StreamsPublishedEvents(t *testing.T) {
+	server := &Server{events: newEventHub(), live: true}
+	ts := httptest.NewServer(http.HandlerFunc(server.handleEvents))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// The client is subscribed once the headers arrive
+	result := createMockResult("https://example.com/", 90, 90, 90, 90, false)
+	server.Publish(types.Event{Type: types.EventStarted, URL: "https://example.com/"})
+	server.Publish(types.Event{Type: types.EventCompleted, URL: "https://example.com/", Result: result})
+	server.events.close()
+
+	var names []string
+	var last types.Event
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if name, ok := strings.CutPrefix(line, "event: "); ok {
+			names = append(names, name)
+		}
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			require.NoError(t, json.Unmarshal([]byte(data), &last))
+		}
+	}
+	assert.Equal(t, []string{"started", "completed"}, names)
+	assert.Equal(t, types.EventCompleted, last.Type)
+	assert.Equal(t, "https://example.com/", last.URL)
+}
+
+func TestPublish_FillsLiveReport(t *testing.T) {
+	server := &Server{events: newEventHub(), live: true}
+
+	server.Publish(types.Event{Type: types.EventQueued, URL: "https://example.com/a"})
+	server.Publish(types.Event{
+		Type:   types.EventCacheHit,
+		URL:    "https://example.com/a",
+		Result: createMockResult("https://example.com/a", 90, 90, 90, 90, false),
+	})
+	server.Publish(types.Event{
+		Type:   types.EventFailed,
+		URL:    "https://example.com/b",
+		Result: createMockResult("https://example.com/b", 0, 0, 0, 0, true),
+	})
+
+	data := server.reportData()
+	assert.True(t, data.Live)
+	assert.Len(t, data.Results, 2)
+	assert.Equal(t, 1, data.Summary.FailedPages)
+
+	final := &types.ReportData{Results: data.Results[:1], Incomplete: true}
+	server.Finish(final)
+	data = server.reportData()
+	assert.False(t, data.Live)
+	assert.True(t, data.Incomplete)
+	assert.Len(t, data.Results, 1)
+}
+
+func TestBegin_ClearsPreviousRun(t *testing.T) {
+	server := &Server{events: newEventHub()}
+	server.Finish(&types.ReportData{Results: []*types.PageResult{{URL: "https://example.com/"}}, Incomplete: true})
+
+	server.Begin()
+	data := server.reportData()
+	assert.True(t, data.Live)
+	assert.False(t, data.Incomplete)
+	assert.Empty(t, data.Results)
+}
+
+func TestHandleReport_LiveBanner(t *testing.T) {
+	server := &Server{events: newEventHub(), live: true}
+
+	req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+	w := httptest.NewRecorder()
+	server.handleReport(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `id="live-run"`)
+	assert.Contains(t, w.Body.String(), "/api/events")
+}
@@ -2,14 +2,13 @@ package server
 
 import (
 	"fmt"
-	"time"
 
 	"github.com/mattjh1/psi-map/internal/types"
 	"github.com/mattjh1/psi-map/internal/utils/validate"
 )
 
-// GenerateHTMLFile generates an HTML file from results without starting a server
-func GenerateHTMLFile(results []*types.PageResult, filename string) error {
+// GenerateHTMLFile generates an HTML file from a report without starting a server
+func GenerateHTMLFile(report *types.ReportData, filename string) error {
 	tmpl, err := loadReportTemplateFromFS()
 	if err != nil {
 		return fmt.Errorf("template parsing error: %v", err)
@@ -21,17 +20,8 @@ func GenerateHTMLFile(results []*types.PageResult, filename string) error {
 	}
 
 	// Create a temporary server instance to generate the summary
-	s := &Server{results: results}
-
-	data := struct {
-		Results   []*types.PageResult
-		Summary   types.ReportSummary
-		Generated time.Time
-	}{
-		Results:   results,
-		Summary:   s.generateSummary(),
-		Generated: time.Now(),
-	}
+	s := &Server{results: report.Results, report: report}
+	data := s.reportData()
 
 	components := validate.SplitFilePath(filename)
 
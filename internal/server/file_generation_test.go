@@ -19,7 +19,7 @@ func TestGenerateHTMLFile(t *testing.T) {
 	tempDir := t.TempDir()
 	filename := filepath.Join(tempDir, "test-report.html")
 
-	err := GenerateHTMLFile(results, filename)
+	err := GenerateHTMLFile(NewReport(results), filename)
 	require.NoError(t, err)
 
 	// Check file was created
@@ -47,7 +47,7 @@ func TestGenerateHTMLFile_WithMultipleResults(t *testing.T) {
 	tempDir := t.TempDir()
 	filename := filepath.Join(tempDir, "multi-report.html")
 
-	err := GenerateHTMLFile(results, filename)
+	err := GenerateHTMLFile(NewReport(results), filename)
 	require.NoError(t, err)
 
 	content, err := os.ReadFile(filename)
@@ -70,7 +70,7 @@ func TestGenerateHTMLFile_WithEmptyResults(t *testing.T) {
 	tempDir := t.TempDir()
 	filename := filepath.Join(tempDir, "empty-report.html")
 
-	err := GenerateHTMLFile(results, filename)
+	err := GenerateHTMLFile(NewReport(results), filename)
 	require.NoError(t, err)
 
 	content, err := os.ReadFile(filename)
@@ -89,7 +89,7 @@ func TestGenerateHTMLFile_InvalidPath(t *testing.T) {
 
 	// Try to write to a non-existent directory without creating parent dirs
 	invalidPath := "/nonexistent/directory/report.html"
-	err := GenerateHTMLFile(results, invalidPath)
+	err := GenerateHTMLFile(NewReport(results), invalidPath)
 	assert.Error(t, err)
 }
 
@@ -103,7 +103,7 @@ func TestGenerateHTMLFile_WithErrorResults(t *testing.T) {
 	tempDir := t.TempDir()
 	filename := filepath.Join(tempDir, "error-report.html")
 
-	err := GenerateHTMLFile(results, filename)
+	err := GenerateHTMLFile(NewReport(results), filename)
 	require.NoError(t, err)
 
 	content, err := os.ReadFile(filename)
@@ -114,3 +114,180 @@ func TestGenerateHTMLFile_WithErrorResults(t *testing.T) {
 	assert.Contains(t, string(content), "https://error.com")
 	assert.Contains(t, string(content), "https://partial.com")
 }
+
+func TestGenerateHTMLFile_WithFilterStats(t *testing.T) {
+	report := NewReport([]*types.PageResult{
+		createMockResult("https://example.com", 90, 85, 80, 95, false),
+	})
+	report.Filter = &types.FilterStats{
+		Total:       10,
+		Kept:        1,
+		Excluded:    9,
+		NotIncluded: 2,
+		Include:     []string{"/blog/**"},
+		Rules:       []types.FilterRuleStat{{Rule: "/tag/**", Excluded: 7}},
+	}
+
+	filename := filepath.Join(t.TempDir(), "filtered-report.html")
+	require.NoError(t, GenerateHTMLFile(report, filename))
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Kept 1 of 10 URLs, excluded 9")
+	assert.Contains(t, string(content), "exclude: /tag/**")
+	assert.Contains(t, string(content), "not matching include: /blog/**")
+}
+
+func TestGenerateHTMLFile_WithDedupeStats(t *testing.T) {
+	report := NewReport([]*types.PageResult{
+		createMockResult("https://example.com/a", 90, 85, 80, 95, false),
+	})
+	report.Dedupe = &types.DedupeStats{
+		Total:     3,
+		Unique:    1,
+		Merged:    2,
+		Rewritten: 2,
+		Duplicates: []types.DuplicateGroup{
+			{URL: "https://example.com/a", Variants: []string{"https://example.com/a", "https://example.com/a?utm_source=x"}},
+		},
+	}
+
+	filename := filepath.Join(t.TempDir(), "dedupe-report.html")
+	require.NoError(t, GenerateHTMLFile(report, filename))
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "1 unique of 3 URLs, merged 2 duplicates")
+	assert.Contains(t, string(content), "https://example.com/a?utm_source=x")
+}
+
+func TestGenerateHTMLFile_WithLocales(t *testing.T) {
+	report := NewReport([]*types.PageResult{
+		createMockResult("https://example.com/en/", 92, 85, 80, 95, false),
+	})
+	report.Locales = []types.LocaleGroup{{
+		Canonical: "https://example.com/en/",
+		Variants: []types.LocaleVariant{
+			{Locale: "de", URL: "https://example.com/de/", Analyzed: true, Scores: map[string]float64{"performance": 55}},
+			{Locale: "en", URL: "https://example.com/en/", Analyzed: true, Scores: map[string]float64{"performance": 92}},
+			{Locale: "fr", URL: "https://example.com/fr/"},
+		},
+		PerformanceGap: 37,
+		SlowestLocale:  "de",
+	}}
+
+	filename := filepath.Join(t.TempDir(), "locale-report.html")
+	require.NoError(t, GenerateHTMLFile(report, filename))
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Locale Comparison")
+	assert.Contains(t, string(content), "de is 37 performance points behind")
+	assert.Contains(t, string(content), "Not analyzed")
+}
+
+func TestGenerateHTMLFile_WithSkippedURLs(t *testing.T) {
+	report := NewReport([]*types.PageResult{
+		createMockResult("https://example.com/", 90, 85, 80, 95, false),
+	})
+	report.Skipped = []types.SkippedURL{
+		{URL: "https://example.com/old", Reason: "HTTP 404", StatusCode: 404},
+		{URL: "https://example.com/brochure.pdf", Reason: "non-HTML content type application/pdf"},
+	}
+
+	filename := filepath.Join(t.TempDir(), "skipped-report.html")
+	require.NoError(t, GenerateHTMLFile(report, filename))
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "2 URLs failed the preflight check")
+	assert.Contains(t, string(content), "non-HTML content type application/pdf")
+}
+
+func TestGenerateHTMLFile_WithSampleClusters(t *testing.T) {
+	report := NewReport([]*types.PageResult{
+		createMockResult("https://example.com/products/shoe", 72, 85, 80, 95, false),
+	})
+	report.Sample = &types.SampleReport{
+		Mode:        types.SampleRandom,
+		PerCluster:  1,
+		TotalURLs:   50,
+		SampledURLs: 2,
+		Clusters: []types.URLCluster{
+			{
+				Pattern:               "/products/:slug",
+				Size:                  49,
+				Sampled:               []string{"https://example.com/products/shoe"},
+				Analyzed:              1,
+				AverageScores:         map[string]float64{"performance": 72},
+				EstimatedDistribution: map[string][]int{"performance": {0, 49, 0}},
+			},
+			{Pattern: "/", Size: 1, Sampled: []string{"https://example.com/"}},
+		},
+		EstimatedScores: map[string]float64{"performance": 72},
+	}
+
+	filename := filepath.Join(t.TempDir(), "sampled-report.html")
+	require.NoError(t, GenerateHTMLFile(report, filename))
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "Template Sampling")
+	assert.Contains(t, string(content), "/products/:slug")
+	assert.Contains(t, string(content), "0 / 49 / 0")
+	assert.Contains(t, string(content), "estimated site-wide performance 72")
+}
+
+func TestGenerateHTMLFile_WithSites(t *testing.T) {
+	acme := createMockResult("https://acme.example/", 91, 85, 80, 95, false)
+	acme.Site = "acme.example"
+	globex := createMockResult("https://globex.example/", 42, 70, 75, 88, false)
+	globex.Site = "globex.example"
+
+	report := NewReport([]*types.PageResult{acme, globex})
+	report.Sites = []types.SiteReport{
+		{
+			Name:    "acme.example",
+			Sitemap: "https://acme.example/sitemap.xml",
+			Summary: GenerateSummary([]*types.PageResult{acme}),
+			Filter:  &types.FilterStats{Total: 3, Kept: 1, Excluded: 2},
+		},
+		{
+			Name:    "globex.example",
+			Sitemap: "https://globex.example/sitemap.xml",
+			Error:   "failed to parse input: unexpected EOF",
+			Summary: GenerateSummary([]*types.PageResult{globex}),
+		},
+	}
+
+	filename := filepath.Join(t.TempDir(), "portfolio-report.html")
+	require.NoError(t, GenerateHTMLFile(report, filename))
+
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `id="portfolio-overview"`)
+	assert.Contains(t, string(content), `id="site-acme.example"`)
+	assert.Contains(t, string(content), "https://globex.example/sitemap.xml")
+	assert.Contains(t, string(content), "average performance 42")
+	assert.Contains(t, string(content), "Input could not be read in full")
+	assert.Contains(t, string(content), "Kept 1 of 3")
+}
+
+func TestGenerateHTMLFile_Incomplete(t *testing.T) {
+	report := NewReport([]*types.PageResult{
+		createMockResult("https://example.com", 90, 85, 80, 95, false),
+	})
+
+	filename := filepath.Join(t.TempDir(), "complete-report.html")
+	require.NoError(t, GenerateHTMLFile(report, filename))
+	content, err := os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), `id="incomplete-run"`)
+
+	report.Incomplete = true
+	filename = filepath.Join(t.TempDir(), "partial-report.html")
+	require.NoError(t, GenerateHTMLFile(report, filename))
+	content, err = os.ReadFile(filename)
+	require.NoError(t, err)
+	assert.Contains(t, string(content), `id="incomplete-run"`)
+}
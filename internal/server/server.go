@@ -14,6 +14,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
@@ -23,24 +24,52 @@ import (
 )
 
 type Server struct {
+	mu      sync.RWMutex
 	results []*types.PageResult
+	report  *types.ReportData
+	live    bool // a run is still adding results
 	port    string
 	server  *http.Server
+	events  *eventHub
 }
 
 // Start initializes and starts the web server
-func Start(results []*types.PageResult, port string) error {
-	log := logger.GetLogger()
+func Start(report *types.ReportData, port string) error {
+	s, err := newServer(port)
+	if err != nil {
+		return err
+	}
+	s.results = report.Results
+	s.report = report
+	s.listen()
+
+	// Wait for interrupt signal
+	return s.waitForShutdown()
+}
 
+// StartLive starts the web server before a run, so the report fills in as
+// pages finish. Feed it with Publish, then call Finish and Wait.
+func StartLive(port string) (*Server, error) {
+	s, err := newServer(port)
+	if err != nil {
+		return nil, err
+	}
+	s.live = true
+	s.listen()
+	return s, nil
+}
+
+// newServer finds a port and sets up the routes
+func newServer(port string) (*Server, error) {
 	// Find an available port if the default is taken
 	availablePort, err := findAvailablePort(port)
 	if err != nil {
-		return fmt.Errorf("failed to find available port: %w", err)
+		return nil, fmt.Errorf("failed to find available port: %w", err)
 	}
 
 	s := &Server{
-		results: results,
-		port:    availablePort,
+		port:   availablePort,
+		events: newEventHub(),
 	}
 
 	// Setup routes
@@ -49,6 +78,7 @@ func Start(results []*types.PageResult, port string) error {
 	mux.HandleFunc("/api/results", s.handleAPIResults)
 	mux.HandleFunc("/api/results/", s.handleAPIResult)
 	mux.HandleFunc("/api/report-data", s.handleReportData)
+	mux.HandleFunc("/api/events", s.handleEvents)
 	mux.HandleFunc("/static/", s.handleStatic)
 
 	s.server = &http.Server{
@@ -59,6 +89,14 @@ func Start(results []*types.PageResult, port string) error {
 		WriteTimeout:      constants.WriteTimeout,
 		IdleTimeout:       constants.IdleTimeout,
 	}
+	// Event streams never finish on their own
+	s.server.RegisterOnShutdown(s.events.close)
+	return s, nil
+}
+
+// listen serves in the background and opens the report in a browser
+func (s *Server) listen() {
+	log := logger.GetLogger()
 
 	// Start server in goroutine
 	go func() {
@@ -74,8 +112,40 @@ func Start(results []*types.PageResult, port string) error {
 		time.Sleep(1 * time.Second) // Give server time to start
 		openBrowser(fmt.Sprintf("http://localhost:%s", s.port))
 	}()
+}
 
-	// Wait for interrupt signal
+// Publish forwards a run event to event stream clients and adds finished
+// pages to the live report
+func (s *Server) Publish(e types.Event) {
+	if e.Result != nil {
+		s.mu.Lock()
+		s.results = append(s.results, e.Result)
+		s.mu.Unlock()
+	}
+	s.events.broadcast(e)
+}
+
+// Begin clears the page for the next live run
+func (s *Server) Begin() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results = nil
+	s.report = nil
+	s.live = true
+}
+
+// Finish replaces the live results with the final report
+func (s *Server) Finish(report *types.ReportData) {
+	s.mu.Lock()
+	s.results = report.Results
+	s.report = report
+	s.live = false
+	s.mu.Unlock()
+	s.events.broadcast(types.Event{Type: types.EventReportReady, Time: time.Now()})
+}
+
+// Wait serves until interrupted, then shuts down
+func (s *Server) Wait() error {
 	return s.waitForShutdown()
 }
 
@@ -90,15 +160,7 @@ func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	data := struct {
-		Results   []*types.PageResult
-		Summary   types.ReportSummary
-		Generated time.Time
-	}{
-		Results:   s.results,
-		Summary:   s.generateSummary(),
-		Generated: time.Now(),
-	}
+	data := s.reportData()
 
 	// Use a bytes.Buffer to catch template output first
 	var buf bytes.Buffer
@@ -113,10 +175,29 @@ func (s *Server) handleReport(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(buf.Bytes())
 }
 
+// reportData builds the template data for the current results, keeping any
+// run details recorded in the report
+func (s *Server) reportData() *types.ReportData {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data := types.ReportData{Generated: time.Now()}
+	if s.report != nil {
+		data = *s.report
+	}
+	data.Results = s.results
+	data.Summary = s.generateSummary()
+	data.Live = s.live
+	return &data
+}
+
 // handleAPIResults serves JSON data for all results
 func (s *Server) handleAPIResults(w http.ResponseWriter, r *http.Request) {
 	log := logger.GetLogger()
 
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(s.results); err != nil {
 		log.Error("Failed to encode API results: %v", err)
@@ -128,6 +209,9 @@ func (s *Server) handleAPIResults(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleAPIResult(w http.ResponseWriter, r *http.Request) {
 	log := logger.GetLogger()
 
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	indexStr := r.URL.Path[len("/api/results/"):]
 	index, err := strconv.Atoi(indexStr)
 	if err != nil || index < 0 || index >= len(s.results) {
@@ -181,6 +265,9 @@ func (s *Server) handleStatic(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleReportData(w http.ResponseWriter, r *http.Request) {
 	log := logger.GetLogger()
 
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	data := struct {
 		Results []*types.PageResult `json:"results"`
 		Summary types.ReportSummary `json:"summary"`
@@ -250,12 +337,15 @@ func openBrowser(url string) {
 
 // waitForShutdown waits for interrupt signal and gracefully shuts down
 func (s *Server) waitForShutdown() error {
-	log := logger.GetLogger()
-
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
+	return s.Close()
+}
 
+// Close shuts the server down gracefully
+func (s *Server) Close() error {
+	log := logger.GetLogger()
 	log.Tagged("SERVER", "Shutting down server gracefully...", "🛑")
 
 	ctx, cancel := context.WithTimeout(context.Background(), constants.ShutdownTimeout)
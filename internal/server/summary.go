@@ -108,3 +108,12 @@ func GenerateSummary(results []*types.PageResult) types.ReportSummary {
 	s := &Server{results: results}
 	return s.generateSummary()
 }
+
+// NewReport wraps results in a report with a freshly generated summary
+func NewReport(results []*types.PageResult) *types.ReportData {
+	return &types.ReportData{
+		Generated: time.Now(),
+		Summary:   GenerateSummary(results),
+		Results:   results,
+	}
+}
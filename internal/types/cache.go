@@ -8,6 +8,7 @@ import (
 // URLCacheDetail represents detailed information about a cached URL
 type URLCacheDetail struct {
 	URL              string    `json:"url"`
+	Strategy         string    `json:"strategy,omitempty"`
 	Age              string    `json:"age"`
 	IsExpired        bool      `json:"is_expired"`
 	IsStale          bool      `json:"is_stale"` // > 50% of TTL
@@ -1,13 +1,35 @@
 package types
 
+import "time"
+
 // AnalysisConfig holds the configuration for analysis
 type AnalysisConfig struct {
 	Sitemap      string
+	Sites        []Site // set when several inputs are analyzed in one run
 	OutputFile   string
 	OutputFormat string
 	UseStdout    bool
 	StartServer  bool
 	ServerPort   string
 	MaxWorkers   int
+	Adaptive     bool // adjust concurrency up to MaxWorkers as PSI responds
+	Retries      int  // extra requests per strategy after transient PSI errors
 	CacheTTL     int
+	FailureTTL   time.Duration // how long failed strategies stay cached
+	Crawl        *CrawlConfig
+	FromDir      *StaticDirConfig
+	Normalize    *NormalizeConfig
+	Hreflang     bool
+	Include      []string
+	Exclude      []string
+	Sample       *SampleConfig
+	Trickle      *TrickleConfig // analyze a bounded number of URLs per run
+	Interactive  bool           // pick sections or pages before analysis
+	Rewrite      *RewriteConfig
+	EventsFile   string // JSON lines log of run events
+	Shard        *ShardConfig
+
+	// Preflight checks URLs are reachable HTML pages before analysis
+	Preflight            bool
+	PreflightConcurrency int
 }
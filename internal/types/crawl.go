@@ -0,0 +1,12 @@
+package types
+
+// CrawlConfig holds the settings for discovering URLs by crawling a site
+type CrawlConfig struct {
+	StartURL     string
+	MaxDepth     int
+	MaxPages     int
+	Concurrency  int
+	Include      []string
+	Exclude      []string
+	IgnoreRobots bool
+}
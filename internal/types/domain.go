@@ -4,14 +4,14 @@ import (
 	"time"
 )
 
-// Sitemap represents the structure of a basic XML sitemap
-type Sitemap struct {
-	URLs []URL `xml:"url"`
-}
-
 // URL represents a single URL entry in a sitemap
 type URL struct {
-	Loc string `xml:"loc"`
+	Loc      string `xml:"loc"`
+	Lastmod  string `xml:"lastmod"`
+	Priority string `xml:"priority"`
+
+	// Alternates are the hreflang links of multilingual sitemaps
+	Alternates []Alternate `xml:"http://www.w3.org/1999/xhtml link"`
 }
 
 // PageResult represents the complete analysis result for a single page
@@ -21,6 +21,12 @@ type PageResult struct {
 	Mobile   *Result
 	Desktop  *Result
 	Duration time.Duration
+
+	// OriginalURL is the sitemap URL when URL was produced by a rewrite rule
+	OriginalURL string `json:",omitempty"`
+
+	// Site is the name of the site the page belongs to in multi-site runs
+	Site string `json:",omitempty"`
 }
 
 // GetRelevantScores returns scores from the result that determined this page's ranking
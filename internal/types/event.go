@@ -0,0 +1,52 @@
+package types
+
+import "time"
+
+// EventType names a step in the life of a URL during a run
+type EventType string
+
+// Run events, in the order a URL normally goes through them
+const (
+	EventQueued           EventType = "queued"
+	EventCacheHit         EventType = "cache_hit"
+	EventStarted          EventType = "started"
+	EventStrategyFinished EventType = "strategy_finished"
+	EventRetry            EventType = "retry"
+	EventCompleted        EventType = "completed"
+	EventFailed           EventType = "failed"
+
+	// EventConcurrency is sent when adaptive concurrency changes the number
+	// of URLs analyzed at once
+	EventConcurrency EventType = "concurrency"
+
+	// EventRunFinished is sent once when no more URLs will be analyzed
+	EventRunFinished EventType = "run_finished"
+
+	// EventReportReady is sent by the web server once the final report,
+	// including cached pages and run details, can be loaded
+	EventReportReady EventType = "report_ready"
+)
+
+// Event reports progress of a run. Fields that do not apply to the event
+// type are left empty.
+type Event struct {
+	Type     EventType `json:"type"`
+	Time     time.Time `json:"time"`
+	URL      string    `json:"url,omitempty"`
+	Strategy string    `json:"strategy,omitempty"`
+	Attempt  int       `json:"attempt,omitempty"` // retry number
+	Error    string    `json:"error,omitempty"`
+
+	// DurationMS is how long the strategy or the whole URL took
+	DurationMS int64           `json:"duration_ms,omitempty"`
+	Scores     *CategoryScores `json:"scores,omitempty"`
+
+	// Concurrency is the new limit of a concurrency event, Previous the
+	// one it replaces and Reason why
+	Concurrency int    `json:"concurrency,omitempty"`
+	Previous    int    `json:"previous,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+
+	// Result is the finished page for completed, failed and cache_hit events
+	Result *PageResult `json:"-"`
+}
@@ -0,0 +1,27 @@
+package types
+
+// Alternate is an <xhtml:link> element listed under a sitemap URL entry
+type Alternate struct {
+	Rel      string `xml:"rel,attr"`
+	Hreflang string `xml:"hreflang,attr"`
+	Href     string `xml:"href,attr"`
+}
+
+// LocaleGroup is one page and its hreflang alternates, with scores compared
+// across locales
+type LocaleGroup struct {
+	Canonical string          `json:"canonical"`
+	Variants  []LocaleVariant `json:"variants"`
+
+	// PerformanceGap is the best minus the worst analyzed performance score
+	PerformanceGap float64 `json:"performance_gap"`
+	SlowestLocale  string  `json:"slowest_locale,omitempty"`
+}
+
+// LocaleVariant is a single locale of a page
+type LocaleVariant struct {
+	Locale   string             `json:"locale"`
+	URL      string             `json:"url"`
+	Analyzed bool               `json:"analyzed"`
+	Scores   map[string]float64 `json:"scores,omitempty"`
+}
@@ -0,0 +1,77 @@
+package types
+
+// InspectOptions controls what `sitemap inspect` checks
+type InspectOptions struct {
+	CheckStatus bool
+	Concurrency int
+}
+
+// SitemapInspection is the health report of a sitemap and its child sitemaps
+type SitemapInspection struct {
+	Input     string   `json:"input"`
+	Domain    string   `json:"domain"`
+	Sitemaps  []string `json:"sitemaps"`
+	TotalURLs int      `json:"total_urls"`
+	ValidURLs int      `json:"valid_urls"`
+
+	Issues     []SitemapIssue      `json:"issues"`
+	Duplicates []DuplicateURL      `json:"duplicates"`
+	OffDomain  []string            `json:"off_domain"`
+	Sections   []SectionCount      `json:"sections"`
+	Lastmod    LastmodDistribution `json:"lastmod"`
+
+	// Status is only set when URLs were probed with --check-status
+	Status *StatusSummary `json:"status,omitempty"`
+}
+
+// SitemapIssue is a schema or content problem found at a line of a sitemap
+type SitemapIssue struct {
+	Sitemap string `json:"sitemap"`
+	Line    int    `json:"line"`
+	URL     string `json:"url,omitempty"`
+	Message string `json:"message"`
+}
+
+// DuplicateURL is a loc listed more than once
+type DuplicateURL struct {
+	URL   string `json:"url"`
+	Count int    `json:"count"`
+}
+
+// SectionCount is the number of URLs under a top-level path section
+type SectionCount struct {
+	Section string `json:"section"`
+	Count   int    `json:"count"`
+}
+
+// LastmodDistribution buckets the lastmod values by age
+type LastmodDistribution struct {
+	Missing int            `json:"missing"`
+	Invalid int            `json:"invalid"`
+	Oldest  string         `json:"oldest,omitempty"`
+	Newest  string         `json:"newest,omitempty"`
+	Buckets []LastmodCount `json:"buckets"`
+}
+
+// LastmodCount is the number of URLs in one lastmod age bucket
+type LastmodCount struct {
+	Label string `json:"label"`
+	Count int    `json:"count"`
+}
+
+// StatusSummary records the outcome of probing every valid URL
+type StatusSummary struct {
+	Checked   int         `json:"checked"`
+	OK        int         `json:"ok"`
+	Redirects int         `json:"redirects"`
+	Errors    int         `json:"errors"`
+	Problems  []URLStatus `json:"problems"`
+}
+
+// URLStatus is a URL that did not answer with 200 OK
+type URLStatus struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Location   string `json:"location,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
@@ -0,0 +1,33 @@
+package types
+
+// Trailing slash policies for URL normalization
+const (
+	TrailingSlashKeep  = "keep"
+	TrailingSlashAdd   = "add"
+	TrailingSlashStrip = "strip"
+)
+
+// NormalizeConfig controls how URLs are rewritten before duplicates are merged
+type NormalizeConfig struct {
+	TrailingSlash string
+	LowercaseHost bool
+	DropFragment  bool
+
+	// DropParams lists query parameters to remove; a trailing * matches a prefix
+	DropParams []string
+}
+
+// DedupeStats records how many URLs were merged after normalization
+type DedupeStats struct {
+	Total      int              `json:"total"`
+	Unique     int              `json:"unique"`
+	Merged     int              `json:"merged"`
+	Rewritten  int              `json:"rewritten"` // URLs changed by normalization
+	Duplicates []DuplicateGroup `json:"duplicates,omitempty"`
+}
+
+// DuplicateGroup lists the original URLs that normalized to the same URL
+type DuplicateGroup struct {
+	URL      string   `json:"url"`
+	Variants []string `json:"variants"`
+}
@@ -0,0 +1,7 @@
+package types
+
+// URLSection is the URLs under one top-level path section, e.g. /blog
+type URLSection struct {
+	Section string
+	URLs    []string
+}
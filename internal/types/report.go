@@ -1,6 +1,8 @@
 package types
 
 import (
+	"encoding/json"
+	"errors"
 	"time"
 )
 
@@ -26,6 +28,40 @@ type Result struct {
 	Opportunities []Opportunity `json:"opportunities,omitempty"`
 }
 
+// resultAlias drops Result's JSON methods to avoid recursion
+type resultAlias Result
+
+// resultJSON stores the error as its message, since error values do not
+// survive a JSON round trip
+type resultJSON struct {
+	resultAlias
+	Error string `json:"error,omitempty"`
+}
+
+// MarshalJSON writes the error as a string
+func (r Result) MarshalJSON() ([]byte, error) {
+	aux := resultJSON{resultAlias: resultAlias(r)}
+	if r.Error != nil {
+		aux.Error = r.Error.Error()
+	}
+	return json.Marshal(aux)
+}
+
+// UnmarshalJSON restores the error from its message, so saved reports and
+// cache entries of failed requests can be read back
+func (r *Result) UnmarshalJSON(data []byte) error {
+	var aux resultJSON
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	*r = Result(aux.resultAlias)
+	r.Error = nil
+	if aux.Error != "" {
+		r.Error = errors.New(aux.Error)
+	}
+	return nil
+}
+
 // ReportSummary contains aggregate statistics
 type ReportSummary struct {
 	TotalPages        int
@@ -43,5 +79,60 @@ type ReportSummary struct {
 type ReportData struct {
 	Generated time.Time     `json:"generated"`
 	Summary   ReportSummary `json:"summary"`
-	Results   []Result      `json:"results"`
+	Results   []*PageResult `json:"results"`
+
+	// Incomplete is set when the run was interrupted before every URL finished
+	Incomplete bool `json:"incomplete,omitempty"`
+
+	// Live is set while the web server shows a run that is still in progress
+	Live bool `json:"-"`
+
+	// Shard is set when the report covers only one shard of the URL list
+	Shard *ShardConfig `json:"shard,omitempty"`
+
+	// Dedupe records which URLs were merged after normalization
+	Dedupe *DedupeStats `json:"dedupe,omitempty"`
+
+	// Filter records how include/exclude rules narrowed the URL list
+	Filter *FilterStats `json:"filter,omitempty"`
+
+	// Skipped lists the URLs the preflight check kept away from PSI
+	Skipped []SkippedURL `json:"skipped,omitempty"`
+
+	// Locales compares scores across the hreflang variants of each page
+	Locales []LocaleGroup `json:"locales,omitempty"`
+
+	// Sample lists the URL clusters found and their extrapolated stats
+	Sample *SampleReport `json:"sample,omitempty"`
+
+	// Trickle records how much of the site a budgeted run analyzed; the
+	// other results come from earlier runs
+	Trickle *TrickleReport `json:"trickle,omitempty"`
+
+	// Sites breaks a multi-site run down per site; the summary above then
+	// covers the whole portfolio
+	Sites []SiteReport `json:"sites,omitempty"`
+}
+
+// FilterStats records how many URLs the include/exclude rules removed
+type FilterStats struct {
+	Total       int              `json:"total"`
+	Kept        int              `json:"kept"`
+	Excluded    int              `json:"excluded"`
+	NotIncluded int              `json:"not_included"` // matched no include rule
+	Include     []string         `json:"include,omitempty"`
+	Rules       []FilterRuleStat `json:"rules,omitempty"`
+}
+
+// FilterRuleStat counts the URLs removed by a single exclude rule
+type FilterRuleStat struct {
+	Rule     string `json:"rule"`
+	Excluded int    `json:"excluded"`
+}
+
+// SkippedURL is a URL that was not analyzed, and why
+type SkippedURL struct {
+	URL        string `json:"url"`
+	Reason     string `json:"reason"`
+	StatusCode int    `json:"status_code,omitempty"`
 }
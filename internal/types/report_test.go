@@ -0,0 +1,30 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResultJSON_RoundTripsError(t *testing.T) {
+	page := &PageResult{
+		URL:     "https://example.com/",
+		Mobile:  &Result{URL: "https://example.com/", Strategy: "mobile", Scores: &CategoryScores{Performance: 88}},
+		Desktop: &Result{URL: "https://example.com/", Strategy: "desktop", Error: errors.New("API error: status 500")},
+	}
+
+	data, err := json.Marshal(page)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"error":"API error: status 500"`)
+
+	var decoded PageResult
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.NoError(t, decoded.Mobile.Error)
+	assert.Equal(t, 88.0, decoded.Mobile.Scores.Performance)
+	require.Error(t, decoded.Desktop.Error)
+	assert.Equal(t, "API error: status 500", decoded.Desktop.Error.Error())
+	assert.Equal(t, "desktop", decoded.Desktop.Strategy)
+}
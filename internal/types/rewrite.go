@@ -0,0 +1,19 @@
+package types
+
+// RewritePair maps a value found in a sitemap URL to its replacement
+type RewritePair struct {
+	From string
+	To   string
+}
+
+// RewriteConfig rewrites sitemap URLs before analysis, e.g. to test a staging
+// host against the production sitemap
+type RewriteConfig struct {
+	Hosts []RewritePair
+
+	// Paths maps path prefixes; the longest matching prefix wins
+	Paths []RewritePair
+
+	// Params are query parameters set on every URL (From is the key, To the value)
+	Params []RewritePair
+}
@@ -0,0 +1,39 @@
+package types
+
+// Sampling modes for choosing representative URLs per cluster
+const (
+	SampleRandom   = "random"
+	SamplePriority = "priority"
+	SampleLastmod  = "lastmod"
+)
+
+// SampleConfig controls template-aware URL sampling
+type SampleConfig struct {
+	PerCluster int
+	Mode       string
+	Seed       int64
+}
+
+// SampleReport describes the URL clusters found and how they were sampled
+type SampleReport struct {
+	Mode        string       `json:"mode"`
+	PerCluster  int          `json:"per_cluster"`
+	TotalURLs   int          `json:"total_urls"`
+	SampledURLs int          `json:"sampled_urls"`
+	Clusters    []URLCluster `json:"clusters"`
+
+	// EstimatedScores are site-wide averages weighted by cluster size
+	EstimatedScores map[string]float64 `json:"estimated_scores,omitempty"`
+}
+
+// URLCluster is a group of URLs sharing one path pattern, e.g. /products/:slug
+type URLCluster struct {
+	Pattern string   `json:"pattern"`
+	Size    int      `json:"size"`
+	Sampled []string `json:"sampled"`
+
+	// Stats of the sampled results, with the distribution scaled to the cluster size
+	Analyzed              int                `json:"analyzed"`
+	AverageScores         map[string]float64 `json:"average_scores,omitempty"`
+	EstimatedDistribution map[string][]int   `json:"estimated_distribution,omitempty"`
+}
@@ -0,0 +1,8 @@
+package types
+
+// ShardConfig selects one of Count disjoint parts of the URL list, so that
+// several jobs can split one run. Index is 1-based.
+type ShardConfig struct {
+	Index int `json:"index"`
+	Count int `json:"count"`
+}
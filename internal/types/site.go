@@ -0,0 +1,22 @@
+package types
+
+// Site is one input of a multi-site run
+type Site struct {
+	Name    string
+	Sitemap string
+}
+
+// SiteReport is the section of a multi-site report covering one site
+type SiteReport struct {
+	Name    string        `json:"name"`
+	Sitemap string        `json:"sitemap"`
+	Error   string        `json:"error,omitempty"` // the input could not be read in full
+	Summary ReportSummary `json:"summary"`
+
+	Dedupe  *DedupeStats   `json:"dedupe,omitempty"`
+	Filter  *FilterStats   `json:"filter,omitempty"`
+	Skipped []SkippedURL   `json:"skipped,omitempty"`
+	Locales []LocaleGroup  `json:"locales,omitempty"`
+	Sample  *SampleReport  `json:"sample,omitempty"`
+	Trickle *TrickleReport `json:"trickle,omitempty"`
+}
@@ -0,0 +1,10 @@
+package types
+
+// StaticDirConfig holds the settings for analyzing a built static site
+type StaticDirConfig struct {
+	Dir     string
+	BaseURL string
+
+	// CleanURLs maps about.html to /about instead of /about.html
+	CleanURLs bool
+}
@@ -0,0 +1,26 @@
+package types
+
+// Trickle orders for re-analyzing cached URLs once every new URL is done
+const (
+	TrickleOldest   = "oldest"
+	TrickleVolatile = "volatile"
+)
+
+// TrickleConfig limits a run to a budget of URLs, so a huge site is
+// analyzed bit by bit over several runs
+type TrickleConfig struct {
+	Budget int
+	Order  string
+}
+
+// TrickleReport describes which URLs a trickle run analyzed and how many
+// are still waiting for a later run
+type TrickleReport struct {
+	Budget   int    `json:"budget"`
+	Order    string `json:"order"`
+	Total    int    `json:"total"`    // URLs in the site
+	New      int    `json:"new"`      // never analyzed before this run
+	Expired  int    `json:"expired"`  // cached results older than the TTL
+	Analyzed int    `json:"analyzed"` // sent for analysis in this run
+	Pending  int    `json:"pending"`  // new or expired URLs left for later runs
+}
@@ -5,12 +5,14 @@ import (
 	"crypto/md5"
 	"encoding/json"
 	"fmt"
-	"io"
+	"math"
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/mattjh1/psi-map/internal/constants"
@@ -19,15 +21,67 @@ import (
 	"github.com/mattjh1/psi-map/internal/utils/validate"
 )
 
-// URLCacheEntry represents a cached result for a single URL
+// URLCacheEntry is the cached result of one strategy for a single URL
 type URLCacheEntry struct {
-	URL        string           `json:"url"`
-	Result     types.PageResult `json:"result"`
-	Timestamp  time.Time        `json:"timestamp"`
-	SitemapURL string           `json:"sitemap_url"`
+	URL        string       `json:"url"`
+	Strategy   string       `json:"strategy"`
+	Profile    string       `json:"profile"` // CacheProfile fingerprint
+	Result     types.Result `json:"result"`
+	Timestamp  time.Time    `json:"timestamp"`
+	SitemapURL string       `json:"sitemap_url"`
+
+	// Change is how far the performance score moved from the entry this one
+	// replaced, used to re-analyze volatile pages first
+	Change float64 `json:"change,omitempty"`
 }
 
-// SitemapCacheIndex tracks which URLs belong to which sitemap
+// page returns the entry as a page result holding just its strategy
+func (e *URLCacheEntry) page() *types.PageResult {
+	page := &types.PageResult{URL: e.URL, Duration: e.Result.Elapsed}
+	result := e.Result
+	setStrategyResult(page, e.Strategy, &result)
+	return page
+}
+
+// CacheSourcePSI marks results fetched from the PageSpeed Insights API
+const CacheSourcePSI = "psi"
+
+// CacheProfile is the analysis configuration results are cached under. Each
+// strategy is cached on its own, and results made under another profile are
+// never served.
+type CacheProfile struct {
+	// Strategies are the strategies a URL needs cached to be served
+	Strategies []string
+	// Categories are the Lighthouse categories requested
+	Categories []string
+	// Source names what produced the results, e.g. CacheSourcePSI
+	Source string
+}
+
+// DefaultCacheProfile is the profile of the psi-map command
+func DefaultCacheProfile() CacheProfile {
+	return CacheProfile{
+		Strategies: []string{"mobile", "desktop"},
+		Categories: PSICategories,
+		Source:     CacheSourcePSI,
+	}
+}
+
+// Fingerprint identifies the settings that change a strategy's result,
+// whatever order they were given in. It covers only the source and the
+// categories, the only such settings psi-map has; a locale or several runs
+// per URL would have to be added here once they can be chosen.
+func (p CacheProfile) Fingerprint() string {
+	categories := slices.Clone(p.Categories)
+	slices.Sort(categories)
+	// #nosec G401 - used only for checksums, not for security
+	sum := md5.Sum([]byte("source=" + p.Source + "\ncategories=" + strings.Join(categories, ",")))
+	return fmt.Sprintf("%x", sum[:4])
+}
+
+// SitemapCacheIndex lists the URLs of one input. It only records
+// membership: results are cached per URL and shared by every input that
+// lists it.
 type SitemapCacheIndex struct {
 	SitemapURL  string            `json:"sitemap_url"`
 	SitemapHash string            `json:"sitemap_hash"`
@@ -69,19 +123,22 @@ func getCacheDir() (string, error) {
 	return psiCacheDir, nil
 }
 
+// calculateSitemapHash keys the index of an input by what it is rather than
+// what it contains, so editing a sitemap keeps its index: remote inputs by
+// URL, local files by absolute path and bare URL lists by their URLs
 func calculateSitemapHash(sitemapPath string, urls []string) (string, error) {
 	// #nosec G401 - used only for checksums, not for security
 	hash := md5.New()
-	if sitemapPath != "" {
-		file, err := validate.SafeOpenFile(sitemapPath)
+	switch {
+	case isRemoteInput(sitemapPath):
+		hash.Write([]byte(sitemapPath))
+	case sitemapPath != "":
+		path, err := filepath.Abs(sitemapPath)
 		if err != nil {
-			return "", fmt.Errorf("failed to open sitemap: %v", err)
-		}
-		defer file.Close()
-		if _, err := io.Copy(hash, file); err != nil {
-			return "", fmt.Errorf("failed to calculate hash: %v", err)
+			return "", fmt.Errorf("failed to resolve sitemap path: %v", err)
 		}
-	} else {
+		hash.Write([]byte(path))
+	default:
 		for _, url := range urls {
 			hash.Write([]byte(url + "\n"))
 		}
@@ -89,10 +146,16 @@ func calculateSitemapHash(sitemapPath string, urls []string) (string, error) {
 	return fmt.Sprintf("%x", hash.Sum(nil)), nil
 }
 
-func getURLCacheFilename(cacheDir, url string) string {
+// urlCacheKey groups every cached entry of the URL
+func urlCacheKey(url string) string {
 	// #nosec G401 - used only for checksums, not for security
-	urlHash := fmt.Sprintf("%x", md5.Sum([]byte(url)))
-	return filepath.Join(cacheDir, "urls", fmt.Sprintf("url-%s.json", urlHash))
+	return fmt.Sprintf("url-%x", md5.Sum([]byte(url)))
+}
+
+// entryCacheKey is the key of the URL's result for one strategy under the
+// profile with the given fingerprint
+func entryCacheKey(url, strategy, fingerprint string) string {
+	return fmt.Sprintf("%s/%s-%s.json", urlCacheKey(url), strategy, fingerprint)
 }
 
 func getSitemapIndexFilename(cacheDir, sitemapHash string) string {
@@ -114,18 +177,7 @@ func loadSitemapIndex(filename string) (*SitemapCacheIndex, bool) {
 }
 
 func saveSitemapIndex(filename string, index *SitemapCacheIndex) error {
-	components := validate.SplitFilePath(filename)
-
-	// Use the secure file creation function
-	file, _, err := validate.SafeCreateFile(components.Dir, components.Name, components.Extension)
-	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
-	}
-	defer file.Close()
-
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(index); err != nil {
+	if err := writeJSONFile(filename, index); err != nil {
 		return fmt.Errorf("failed to encode sitemap index to %s: %w", filename, err)
 	}
 	return nil
@@ -146,157 +198,370 @@ func loadURLCacheEntry(filename string) (*URLCacheEntry, error) {
 }
 
 func saveURLCacheEntry(filename string, entry *URLCacheEntry) error {
-	components := validate.SplitFilePath(filename)
+	if err := writeJSONFile(filename, entry); err != nil {
+		return fmt.Errorf("failed to save URL to cache %s: %w", filename, err)
+	}
+	return nil
+}
 
-	// Use the secure file creation function
-	file, _, err := validate.SafeCreateFile(components.Dir, components.Name, components.Extension)
+// writeJSONFile writes v as indented JSON to a temporary file and renames it
+// into place, so a crash never leaves a half-written cache file behind. The
+// temporary file is unique, so concurrent writers of the same file never
+// mix their output.
+func writeJSONFile(filename string, v any) error {
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, constants.DefaultDirPermissions); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	file, err := os.CreateTemp(dir, "tmp-*")
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
-	defer file.Close()
+	tmpPath := file.Name()
 
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(entry); err != nil {
-		return fmt.Errorf("failed to save URL to cache %s: %w", filename, err)
+	if err := encoder.Encode(v); err != nil {
+		_ = file.Close()
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := file.Close(); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, filename); err != nil {
+		_ = os.Remove(tmpPath)
+		return err
 	}
 	return nil
 }
 
-func CheckURLCache(sitemapPath string, urls []string, ttlHours int) ([]*types.PageResult, []string, error) {
-	log := logger.GetLogger()
-	cacheDir, err := getCacheDir()
-	if err != nil {
-		return nil, urls, err
-	}
+// URLCache looks up and stores cached results under one profile, keeping
+// the index of one input up to date. Results are found whatever input they
+// were analyzed for, and each strategy expires on its own. The index is
+// written every CacheIndexFlushEvery changes and by Flush, which callers
+// run once the run is done. It is safe for concurrent use once FailureTTL
+// is set.
+type URLCache struct {
+	// FailureTTL is how long a failed strategy is served before it is
+	// analyzed again; 0 analyzes failures again on every run
+	FailureTTL time.Duration
+
+	store       CacheStore
+	profile     CacheProfile
+	fingerprint string
+	sitemapPath string
+	hash        string
+	ttlHours    int
+
+	mu      sync.Mutex
+	index   *SitemapCacheIndex
+	unsaved int                 // index changes not written yet
+	resumed map[string][]string // URL -> strategies served by Resume
+}
 
-	urlsCacheDir := filepath.Join(cacheDir, "urls")
-	indexesCacheDir := filepath.Join(cacheDir, "indexes")
-	if err := os.MkdirAll(urlsCacheDir, constants.DefaultDirPermissions); err != nil {
-		return nil, urls, fmt.Errorf("failed to create URLs cache directory: %v", err)
-	}
-	if err := os.MkdirAll(indexesCacheDir, constants.DefaultDirPermissions); err != nil {
-		return nil, urls, fmt.Errorf("failed to create indexes cache directory: %v", err)
+// CachedPage is the cached result of every strategy of a URL
+type CachedPage struct {
+	Result *types.PageResult
+	// Analyzed is when the oldest of its strategies was analyzed
+	Analyzed time.Time
+	// Change is the largest score change of any of its strategies
+	Change float64
+	// Expired lists the strategies too old to serve
+	Expired []string
+}
+
+// OpenURLCache loads the cache index of the sitemap from the selected
+// backend, with the profile of the psi-map command. urls is only used to
+// key inputs that have neither a local file nor a URL.
+func OpenURLCache(sitemapPath string, urls []string, ttlHours int) (*URLCache, error) {
+	store, err := OpenCacheStore("")
+	if err != nil {
+		return nil, err
 	}
+	return NewURLCache(store, DefaultCacheProfile(), sitemapPath, urls, ttlHours)
+}
 
+// NewURLCache loads the cache index of the sitemap from the store
+func NewURLCache(store CacheStore, profile CacheProfile, sitemapPath string, urls []string, ttlHours int) (*URLCache, error) {
 	currentHash, err := calculateSitemapHash(sitemapPath, urls)
 	if err != nil {
-		return nil, urls, err
+		return nil, err
 	}
 
-	indexFile := getSitemapIndexFilename(cacheDir, currentHash)
-	index, indexExists := loadSitemapIndex(indexFile)
-	if !indexExists {
-		return nil, urls, nil
+	index, _ := store.LoadIndex(currentHash)
+	return &URLCache{
+		FailureTTL:  constants.DefaultFailureTTL,
+		store:       store,
+		profile:     profile,
+		fingerprint: profile.Fingerprint(),
+		sitemapPath: sitemapPath,
+		hash:        currentHash,
+		ttlHours:    ttlHours,
+		index:       index,
+		resumed:     make(map[string][]string),
+	}, nil
+}
+
+// Lookup returns the cached result for the URL when no strategy has
+// expired. Expired entries are left for Save to replace, so it can tell how
+// much the scores changed. A result analyzed for another input is served
+// too, and the URL joins this input's index.
+func (c *URLCache) Lookup(url string) (*types.PageResult, bool) {
+	page, ok := c.Page(url)
+	if !ok || c.IsExpired(page) {
+		return nil, false
 	}
 
-	now := time.Now()
-	cached := make([]*types.PageResult, 0)
-	missing := make([]string, 0)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.join(url) {
+		if err := c.changed(); err != nil {
+			logger.GetLogger().Error("failed to update the cache index of %s: %v", c.sitemapPath, err)
+		}
+	}
+	return page.Result, true
+}
 
-	for _, url := range urls {
-		cacheFilename, exists := index.URLs[url]
-		if !exists {
-			missing = append(missing, url)
+// Resume returns the strategies of the URL that are still fresh as a
+// partial result, along with the strategies to analyze again. It returns nil
+// when there is nothing to reuse. Save leaves the reused strategies as they
+// are, so they keep their age.
+func (c *URLCache) Resume(url string) (*types.PageResult, []string) {
+	if c == nil {
+		return nil, nil
+	}
+	partial := &types.PageResult{URL: url}
+	var served, missing []string
+	now := time.Now()
+	for _, strategy := range c.profile.Strategies {
+		entry, err := c.store.LoadEntry(entryCacheKey(url, strategy, c.fingerprint))
+		if err != nil || c.expired(entry, now) {
+			missing = append(missing, strategy)
 			continue
 		}
+		setStrategyResult(partial, strategy, &entry.Result)
+		served = append(served, strategy)
+	}
+	if len(served) == 0 || len(missing) == 0 {
+		return nil, nil
+	}
 
-		cacheFile := filepath.Join(cacheDir, "urls", cacheFilename)
-		entry, err := loadURLCacheEntry(cacheFile)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.resumed[url] = served
+	return partial, missing
+}
+
+// Page returns the cached result of the URL whatever its age, listing the
+// expired strategies. It is only found when every strategy of the profile
+// is cached, for this input or any other.
+func (c *URLCache) Page(url string) (*CachedPage, bool) {
+	if c == nil {
+		return nil, false
+	}
+
+	page := &CachedPage{Result: &types.PageResult{URL: url}}
+	now := time.Now()
+	for _, strategy := range c.profile.Strategies {
+		entry, err := c.store.LoadEntry(entryCacheKey(url, strategy, c.fingerprint))
 		if err != nil {
-			missing = append(missing, url)
-			continue
+			return nil, false
 		}
+		if c.expired(entry, now) {
+			page.Expired = append(page.Expired, strategy)
+		}
+		setStrategyResult(page.Result, strategy, &entry.Result)
+		page.Result.Duration = max(page.Result.Duration, entry.Result.Elapsed)
+		if page.Analyzed.IsZero() || entry.Timestamp.Before(page.Analyzed) {
+			page.Analyzed = entry.Timestamp
+		}
+		page.Change = max(page.Change, entry.Change)
+	}
+	return page, true
+}
 
-		if ttlHours > 0 {
-			expiryTime := entry.Timestamp.Add(time.Duration(ttlHours) * time.Hour)
-			if now.After(expiryTime) {
-				missing = append(missing, url)
-
-				if err := os.Remove(cacheFile); err != nil {
-					log.Error("failed to remove cache file %s: %v", cacheFile, err)
-				}
+// IsExpired reports whether any strategy of the page has expired
+func (c *URLCache) IsExpired(page *CachedPage) bool {
+	return len(page.Expired) > 0
+}
 
-				continue
-			}
+// join lists the URL in the input's index and reports whether it was new
+// to it. The caller holds mu.
+func (c *URLCache) join(url string) bool {
+	if c.index == nil {
+		c.index = &SitemapCacheIndex{
+			SitemapURL:  c.sitemapPath,
+			SitemapHash: c.hash,
+			URLs:        make(map[string]string),
 		}
-		cached = append(cached, &entry.Result)
 	}
+	if _, ok := c.index.URLs[url]; ok {
+		return false
+	}
+	c.index.URLs[url] = urlCacheKey(url)
+	return true
+}
 
-	return cached, missing, nil
+// changed counts a change to the index, writing it once enough have
+// accumulated. The caller holds mu.
+func (c *URLCache) changed() error {
+	c.unsaved++
+	if c.unsaved < constants.CacheIndexFlushEvery {
+		return nil
+	}
+	return c.flush()
 }
 
-func SaveURLCache(sitemapPath string, allURLs []string, newResults []*types.PageResult) error {
-	cacheDir, err := getCacheDir()
-	if err != nil {
+// flush writes the index when it has unsaved changes. The caller holds mu.
+func (c *URLCache) flush() error {
+	if c.unsaved == 0 || c.index == nil {
+		return nil
+	}
+	c.index.LastUpdated = time.Now()
+	if err := c.store.SaveIndex(c.index); err != nil {
 		return err
 	}
+	c.unsaved = 0
+	return nil
+}
 
-	sitemapHash, err := calculateSitemapHash(sitemapPath, allURLs)
-	if err != nil {
-		return err
+// Flush writes the index if it changed since it was last written
+func (c *URLCache) Flush() error {
+	if c == nil {
+		return nil
 	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.flush()
+}
 
-	indexFile := getSitemapIndexFilename(cacheDir, sitemapHash)
-	index, _ := loadSitemapIndex(indexFile)
-	if index == nil {
-		index = &SitemapCacheIndex{
-			SitemapURL:  sitemapPath,
-			SitemapHash: sitemapHash,
-			URLs:        make(map[string]string),
-			LastUpdated: time.Now(),
-		}
+// expired reports whether the entry is too old to serve. A failed strategy
+// expires after FailureTTL, a successful one after the cache TTL.
+func (c *URLCache) expired(entry *URLCacheEntry, now time.Time) bool {
+	return entryExpired(entry, now, c.ttlHours, c.FailureTTL)
+}
+
+// entryExpired reports whether the entry is too old to serve: a failed
+// strategy after failureTTL, a successful one after ttlHours, or never when
+// ttlHours is 0
+func entryExpired(entry *URLCacheEntry, now time.Time, ttlHours int, failureTTL time.Duration) bool {
+	if entry.Result.Error != nil {
+		return !now.Before(entry.Timestamp.Add(failureTTL))
+	}
+	if ttlHours <= 0 {
+		return false
 	}
+	return now.After(entry.Timestamp.Add(time.Duration(ttlHours) * time.Hour))
+}
+
+// Save persists each strategy of one result right away, so an interrupted
+// run can resume from it, and records the URL in the input's index.
+// Strategies served by Resume are not written again.
+func (c *URLCache) Save(result *types.PageResult) error {
+	now := time.Now()
+	c.mu.Lock()
+	served := c.resumed[result.URL]
+	delete(c.resumed, result.URL)
+	c.mu.Unlock()
 
-	for _, result := range newResults {
+	for strategy, r := range strategyResults(result) {
+		if slices.Contains(served, strategy) {
+			continue
+		}
 		entry := URLCacheEntry{
 			URL:        result.URL,
-			Result:     *result,
-			Timestamp:  time.Now(),
-			SitemapURL: sitemapPath,
+			Strategy:   strategy,
+			Profile:    c.fingerprint,
+			Result:     *r,
+			Timestamp:  now,
+			SitemapURL: c.sitemapPath,
+		}
+		key := entryCacheKey(result.URL, strategy, c.fingerprint)
+		if previous, err := c.store.LoadEntry(key); err == nil {
+			entry.Change = scoreChange(previous.page(), entry.page())
 		}
+		if err := c.store.SaveEntry(key, &entry); err != nil {
+			return fmt.Errorf("failed to save %s cache entry for %s: %v", strategy, result.URL, err)
+		}
+	}
 
-		cacheFile := getURLCacheFilename(cacheDir, result.URL)
-		filename := filepath.Base(cacheFile)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.join(result.URL)
+	return c.changed()
+}
 
-		if err := saveURLCacheEntry(cacheFile, &entry); err != nil {
-			return fmt.Errorf("failed to save cache entry for %s: %v", result.URL, err)
-		}
-		index.URLs[result.URL] = filename
+// strategyResults returns the result of each strategy the page was analyzed with
+func strategyResults(page *types.PageResult) map[string]*types.Result {
+	results := make(map[string]*types.Result, 2)
+	if page.Mobile != nil {
+		results["mobile"] = page.Mobile
+	}
+	if page.Desktop != nil {
+		results["desktop"] = page.Desktop
 	}
+	return results
+}
 
-	index.LastUpdated = time.Now()
-	return saveSitemapIndex(indexFile, index)
+// setStrategyResult stores the result of one strategy on the page
+func setStrategyResult(page *types.PageResult, strategy string, result *types.Result) {
+	switch strategy {
+	case "mobile":
+		page.Mobile = result
+	case "desktop":
+		page.Desktop = result
+	}
 }
 
-func getVerboseCacheInfo(cacheDir string, index *SitemapCacheIndex, ttlHours int) (validCount, expiredCount, staleCount int, totalSize int64, avgScore float64) {
+// urlEntries loads every cached entry of the URL, under any profile, along
+// with their keys
+func urlEntries(store CacheStore, url string) (keys []string, entries []*URLCacheEntry) {
+	all, err := store.EntryKeys(urlCacheKey(url))
+	if err != nil {
+		return nil, nil
+	}
+	for _, key := range all {
+		if entry, err := store.LoadEntry(key); err == nil {
+			keys = append(keys, key)
+			entries = append(entries, entry)
+		}
+	}
+	return keys, entries
+}
+
+func getVerboseCacheInfo(store CacheStore, index *SitemapCacheIndex, ttlHours int) (validCount, expiredCount, staleCount int, totalSize int64, avgScore float64) {
 	scoreCount := 0
 	var totalScore float64
 	now := time.Now()
 	stalePeriod := time.Duration(float64(ttlHours)*0.5) * time.Hour
 
-	for _, filename := range index.URLs {
-		cacheFile := filepath.Join(cacheDir, "urls", filename)
-		urlEntry, err := loadURLCacheEntry(cacheFile)
-		if err != nil {
+	for url := range index.URLs {
+		keys, entries := urlEntries(store, url)
+		if len(entries) == 0 {
 			expiredCount++
 			continue
 		}
 
-		if fileInfo, err := os.Stat(cacheFile); err == nil {
-			totalSize += fileInfo.Size()
-		}
-
-		if score := extractPerformanceScore(&urlEntry.Result); score > 0 {
-			totalScore += score
-			scoreCount++
+		// A URL is as fresh as its oldest strategy
+		oldest := entries[0].Timestamp
+		for i, entry := range entries {
+			totalSize += store.EntrySize(keys[i])
+			if score := extractPerformanceScore(entry.page()); score > 0 {
+				totalScore += score
+				scoreCount++
+			}
+			if entry.Timestamp.Before(oldest) {
+				oldest = entry.Timestamp
+			}
 		}
 
 		if ttlHours > 0 {
-			expiryTime := urlEntry.Timestamp.Add(time.Duration(ttlHours) * time.Hour)
+			expiryTime := oldest.Add(time.Duration(ttlHours) * time.Hour)
 			if now.After(expiryTime) {
 				expiredCount++
-			} else if now.After(urlEntry.Timestamp.Add(stalePeriod)) {
+			} else if now.After(oldest.Add(stalePeriod)) {
 				staleCount++
 			} else {
 				validCount++
@@ -314,33 +579,22 @@ func getVerboseCacheInfo(cacheDir string, index *SitemapCacheIndex, ttlHours int
 }
 
 func ListCacheFiles(ttlHours int, verbose bool) ([]types.CacheInfo, error) {
-	cacheDir, err := getCacheDir()
+	store, err := OpenCacheStore("")
 	if err != nil {
 		return nil, err
 	}
 
-	indexesDir := filepath.Join(cacheDir, "indexes")
-	entries, err := os.ReadDir(indexesDir)
+	indexes, err := store.Indexes()
 	if err != nil {
 		return []types.CacheInfo{}, nil
 	}
 
-	cacheInfos := make([]types.CacheInfo, 0, len(entries))
+	cacheInfos := make([]types.CacheInfo, 0, len(indexes))
 	now := time.Now()
 
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasPrefix(entry.Name(), "sitemap-") {
-			continue
-		}
-
-		indexFile := filepath.Join(indexesDir, entry.Name())
-		index, exists := loadSitemapIndex(indexFile)
-		if !exists {
-			continue
-		}
-
+	for _, index := range indexes {
 		cacheInfo := types.CacheInfo{
-			Filename:   entry.Name(),
+			Filename:   fmt.Sprintf("sitemap-%s.json", index.SitemapHash),
 			Hash:       index.SitemapHash[:8],
 			FullHash:   index.SitemapHash,
 			SitemapURL: index.SitemapURL,
@@ -349,7 +603,7 @@ func ListCacheFiles(ttlHours int, verbose bool) ([]types.CacheInfo, error) {
 		}
 
 		if verbose {
-			validCount, expiredCount, staleCount, totalSize, avgScore := getVerboseCacheInfo(cacheDir, index, ttlHours)
+			validCount, expiredCount, staleCount, totalSize, avgScore := getVerboseCacheInfo(store, index, ttlHours)
 			cacheInfo.ValidCount = validCount
 			cacheInfo.ExpiredCount = expiredCount
 			cacheInfo.StaleCount = staleCount
@@ -371,174 +625,142 @@ func CleanExpiredCacheFiles(ttlHours int, dryRun bool) (int, error) {
 		return 0, fmt.Errorf("TTL must be positive")
 	}
 
-	cacheDir, err := getCacheDir()
+	store, err := OpenCacheStore("")
 	if err != nil {
 		return 0, err
 	}
 
-	indexesDir := filepath.Join(cacheDir, "indexes")
-	entries, err := os.ReadDir(indexesDir)
-	if err != nil {
-		return 0, nil
-	}
-
-	cleaned := 0
+	// Every entry is checked, including those no index lists any more, with
+	// the same rule lookups use, so failures expire after the failure TTL
 	now := time.Now()
-
-	for _, entry := range entries {
-		if entry.IsDir() || !strings.HasPrefix(entry.Name(), "sitemap-") {
-			continue
-		}
-
-		indexFile := filepath.Join(indexesDir, entry.Name())
-		index, exists := loadSitemapIndex(indexFile)
-		if !exists {
-			continue
+	var expired []string
+	err = store.ForEachEntry(func(key string, entry *URLCacheEntry) error {
+		if entryExpired(entry, now, ttlHours, constants.DefaultFailureTTL) {
+			expired = append(expired, key)
 		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache entries: %w", err)
+	}
 
-		updatedURLs := make(map[string]string)
-		urlsRemoved := 0
-
-		for url, filename := range index.URLs {
-			cacheFile := filepath.Join(cacheDir, "urls", filename)
-			urlEntry, err := loadURLCacheEntry(cacheFile)
-			if err != nil {
-				urlsRemoved++
+	entriesRemoved := 0
+	for _, key := range expired {
+		if !dryRun {
+			if err := store.DeleteEntry(key); err != nil {
+				log.Tagged("CACHE", "Failed to remove cache entry %s: %v", "⚠️", key, err)
 				continue
 			}
-
-			expiryTime := urlEntry.Timestamp.Add(time.Duration(ttlHours) * time.Hour)
-			if now.After(expiryTime) {
-				if !dryRun {
-					if err := os.Remove(cacheFile); err == nil {
-						urlsRemoved++
-					}
-				} else {
-					urlsRemoved++
-				}
-			} else {
-				updatedURLs[url] = filename
-			}
-		}
-
-		cleaned += urlsRemoved
-		if !dryRun && len(updatedURLs) != len(index.URLs) {
-			if len(updatedURLs) == 0 {
-				if err := os.Remove(indexFile); err != nil {
-					log.Tagged("CACHE", "Failed to remove index file %s: %v", "⚠️", indexFile, err)
-				}
-			} else {
-				index.URLs = updatedURLs
-				index.LastUpdated = now
-				if err := saveSitemapIndex(indexFile, index); err != nil {
-					return -1, fmt.Errorf("warning: failed to save sitemap index: %w", err)
-				}
-			}
 		}
+		entriesRemoved++
+	}
+	if dryRun {
+		return entriesRemoved, nil
 	}
 
-	return cleaned, nil
-}
-
-func ClearAllCacheFiles() (int, error) {
-	log := logger.GetLogger()
-	cacheDir, err := getCacheDir()
+	// Drop URLs without any entry left from the indexes listing them
+	indexes, err := store.Indexes()
 	if err != nil {
-		return 0, err
+		return entriesRemoved, nil
 	}
-
-	clearedCount := 0
-	urlsDir := filepath.Join(cacheDir, "urls")
-	indexesDir := filepath.Join(cacheDir, "indexes")
-
-	if urlEntries, err := os.ReadDir(urlsDir); err == nil {
-		for _, entry := range urlEntries {
-			if !entry.IsDir() && strings.HasPrefix(entry.Name(), "url-") && strings.HasSuffix(entry.Name(), ".json") {
-				if err := os.Remove(filepath.Join(urlsDir, entry.Name())); err == nil {
-					clearedCount++
-				}
+	for _, index := range indexes {
+		updatedURLs := make(map[string]string, len(index.URLs))
+		for url, urlKey := range index.URLs {
+			if keys, _ := urlEntries(store, url); len(keys) > 0 {
+				updatedURLs[url] = urlKey
 			}
 		}
-		if err := os.RemoveAll(urlsDir); err != nil {
-			log.Tagged("CACHE", "Failed to remove URLs directory %s: %v", "⚠️", urlsDir, err)
+		if len(updatedURLs) == len(index.URLs) {
+			continue
 		}
-	}
-
-	if indexEntries, err := os.ReadDir(indexesDir); err == nil {
-		for _, entry := range indexEntries {
-			if !entry.IsDir() && strings.HasPrefix(entry.Name(), "sitemap-") && strings.HasSuffix(entry.Name(), ".json") {
-				if err := os.Remove(filepath.Join(indexesDir, entry.Name())); err == nil {
-					clearedCount++
-				}
+		if len(updatedURLs) == 0 {
+			if err := store.DeleteIndex(index.SitemapHash); err != nil {
+				log.Tagged("CACHE", "Failed to remove sitemap index %s: %v", "⚠️", index.SitemapHash, err)
 			}
+			continue
 		}
-		if err := os.RemoveAll(indexesDir); err != nil {
-			log.Tagged("CACHE", "Failed to remove indexes directory %s: %v", "⚠️", urlsDir, err)
+		index.URLs = updatedURLs
+		index.LastUpdated = now
+		if err := store.SaveIndex(index); err != nil {
+			return -1, fmt.Errorf("warning: failed to save sitemap index: %w", err)
 		}
 	}
 
-	return clearedCount, nil
+	return entriesRemoved, nil
+}
+
+func ClearAllCacheFiles() (int, error) {
+	store, err := OpenCacheStore("")
+	if err != nil {
+		return 0, err
+	}
+	return store.Clear()
 }
 
 func GetURLCacheDetails(sitemapHash string, ttlHours int) ([]types.URLCacheDetail, error) {
-	cacheDir, err := getCacheDir()
+	store, err := OpenCacheStore("")
 	if err != nil {
 		return nil, err
 	}
 
-	indexFile := getSitemapIndexFilename(cacheDir, sitemapHash)
-	index, exists := loadSitemapIndex(indexFile)
+	index, exists := store.LoadIndex(sitemapHash)
 	if !exists {
 		return nil, fmt.Errorf("sitemap index not found")
 	}
 
 	details := make([]types.URLCacheDetail, 0, len(index.URLs))
 	now := time.Now()
-	stalePeriod := time.Duration(float64(ttlHours)*0.5) * time.Hour
 
-	for url, filename := range index.URLs {
-		cacheFile := filepath.Join(cacheDir, "urls", filename)
-		urlEntry, err := loadURLCacheEntry(cacheFile)
-		if err != nil {
-			continue
+	for url := range index.URLs {
+		keys, entries := urlEntries(store, url)
+		for i, urlEntry := range entries {
+			details = append(details, entryDetail(urlEntry, store.EntrySize(keys[i]), ttlHours, now))
 		}
+	}
 
-		fileInfo, err := os.Stat(cacheFile)
-		cacheSize := int64(0)
-		if err == nil {
-			cacheSize = fileInfo.Size()
-		}
+	sort.Slice(details, func(i, j int) bool {
+		return details[i].Timestamp.After(details[j].Timestamp)
+	})
 
-		age := now.Sub(urlEntry.Timestamp)
-		isExpired := false
-		isStale := false
+	return details, nil
+}
 
-		if ttlHours > 0 {
-			expiryTime := urlEntry.Timestamp.Add(time.Duration(ttlHours) * time.Hour)
-			isExpired = now.After(expiryTime)
-			if !isExpired {
-				isStale = now.After(urlEntry.Timestamp.Add(stalePeriod))
-			}
-		}
+// entryDetail describes one cached strategy result for cache listings
+func entryDetail(urlEntry *URLCacheEntry, size int64, ttlHours int, now time.Time) types.URLCacheDetail {
+	stalePeriod := time.Duration(float64(ttlHours)*0.5) * time.Hour
+	age := now.Sub(urlEntry.Timestamp)
+	isExpired := false
+	isStale := false
 
-		detail := types.URLCacheDetail{
-			URL:              url,
-			Age:              formatDuration(age),
-			IsExpired:        isExpired,
-			IsStale:          isStale,
-			PerformanceScore: extractPerformanceScore(&urlEntry.Result),
-			CacheSize:        cacheSize,
-			Timestamp:        urlEntry.Timestamp,
-			HasErrors:        hasErrors(&urlEntry.Result),
+	if ttlHours > 0 {
+		expiryTime := urlEntry.Timestamp.Add(time.Duration(ttlHours) * time.Hour)
+		isExpired = now.After(expiryTime)
+		if !isExpired {
+			isStale = now.After(urlEntry.Timestamp.Add(stalePeriod))
 		}
-		details = append(details, detail)
 	}
 
-	sort.Slice(details, func(i, j int) bool {
-		return details[i].Timestamp.After(details[j].Timestamp)
-	})
+	return types.URLCacheDetail{
+		URL:              urlEntry.URL,
+		Strategy:         urlEntry.Strategy,
+		Age:              formatDuration(age),
+		IsExpired:        isExpired,
+		IsStale:          isStale,
+		PerformanceScore: extractPerformanceScore(urlEntry.page()),
+		CacheSize:        size,
+		Timestamp:        urlEntry.Timestamp,
+		HasErrors:        hasErrors(urlEntry.page()),
+	}
+}
 
-	return details, nil
+// scoreChange returns how far the performance score moved between two
+// results, or 0 when either has no score
+func scoreChange(previous, current *types.PageResult) float64 {
+	before, after := extractPerformanceScore(previous), extractPerformanceScore(current)
+	if before == 0 || after == 0 {
+		return 0
+	}
+	return math.Abs(after - before)
 }
 
 func extractPerformanceScore(result *types.PageResult) float64 {
@@ -0,0 +1,219 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mattjh1/psi-map/internal/constants"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	entriesBucket = []byte("entries")
+	indexesBucket = []byte("indexes")
+)
+
+// boltStore keeps the whole cache in one bbolt database: entries and
+// sitemap indexes are JSON values in their own buckets. Only one process
+// can have the database open at a time.
+type boltStore struct {
+	db *bolt.DB
+}
+
+func openBoltStore(path string) (*boltStore, error) {
+	db, err := bolt.Open(path, constants.DefaultFilePermissions, &bolt.Options{Timeout: constants.CacheLockTimeout})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("cache database %s is in use by another psi-map process", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache database %s: %w", path, err)
+	}
+	removed := 0
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{entriesBucket, indexesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		// Entries of older versions were keyed by URL alone
+		var legacy [][]byte
+		c := tx.Bucket(entriesBucket).Cursor()
+		for k, _ := c.Seek([]byte("url-")); k != nil && bytes.HasPrefix(k, []byte("url-")); k, _ = c.Next() {
+			if isLegacyEntryKey(string(k)) {
+				legacy = append(legacy, k)
+			}
+		}
+		for _, k := range legacy {
+			if err := tx.Bucket(entriesBucket).Delete(k); err != nil {
+				return err
+			}
+		}
+		removed = len(legacy)
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to prepare cache database %s: %w", path, err)
+	}
+	logLegacyEntries(removed)
+	return &boltStore{db: db}, nil
+}
+
+func (s *boltStore) Backend() string { return CacheBackendBolt }
+
+// get decodes the value under key in the bucket into v
+func (s *boltStore) get(bucket []byte, key string, v any) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(bucket).Get([]byte(key))
+		if data == nil {
+			return fmt.Errorf("%s not found in cache database", key)
+		}
+		return json.Unmarshal(data, v)
+	})
+}
+
+// put stores v as JSON under key in the bucket
+func (s *boltStore) put(bucket []byte, key string, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Put([]byte(key), data)
+	})
+}
+
+func (s *boltStore) remove(bucket []byte, key string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucket).Delete([]byte(key))
+	})
+}
+
+func (s *boltStore) LoadEntry(key string) (*URLCacheEntry, error) {
+	var entry URLCacheEntry
+	if err := s.get(entriesBucket, key, &entry); err != nil {
+		return nil, fmt.Errorf("failed to load URL cache entry %s: %w", key, err)
+	}
+	return &entry, nil
+}
+
+func (s *boltStore) SaveEntry(key string, entry *URLCacheEntry) error {
+	if err := s.put(entriesBucket, key, entry); err != nil {
+		return fmt.Errorf("failed to save URL to cache %s: %w", key, err)
+	}
+	return nil
+}
+
+// SaveEntries writes all entries in a single transaction
+func (s *boltStore) SaveEntries(entries map[string]*URLCacheEntry) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(entriesBucket)
+		for key, entry := range entries {
+			data, err := json.Marshal(entry)
+			if err != nil {
+				return fmt.Errorf("failed to encode URL cache entry %s: %w", key, err)
+			}
+			if err := bucket.Put([]byte(key), data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *boltStore) DeleteEntry(key string) error {
+	return s.remove(entriesBucket, key)
+}
+
+func (s *boltStore) EntrySize(key string) int64 {
+	var size int64
+	_ = s.db.View(func(tx *bolt.Tx) error {
+		size = int64(len(tx.Bucket(entriesBucket).Get([]byte(key))))
+		return nil
+	})
+	return size
+}
+
+func (s *boltStore) EntryKeys(urlKey string) ([]string, error) {
+	prefix := []byte(urlKey + "/")
+	var keys []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(entriesBucket).Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			keys = append(keys, string(k))
+		}
+		return nil
+	})
+	return keys, err
+}
+
+func (s *boltStore) ForEachEntry(fn func(key string, entry *URLCacheEntry) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(entriesBucket).ForEach(func(k, v []byte) error {
+			var entry URLCacheEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return nil
+			}
+			return fn(string(k), &entry)
+		})
+	})
+}
+
+func (s *boltStore) LoadIndex(sitemapHash string) (*SitemapCacheIndex, bool) {
+	var index SitemapCacheIndex
+	if err := s.get(indexesBucket, sitemapHash, &index); err != nil {
+		return nil, false
+	}
+	return &index, true
+}
+
+func (s *boltStore) SaveIndex(index *SitemapCacheIndex) error {
+	if err := s.put(indexesBucket, index.SitemapHash, index); err != nil {
+		return fmt.Errorf("failed to encode sitemap index %s: %w", index.SitemapHash, err)
+	}
+	return nil
+}
+
+func (s *boltStore) DeleteIndex(sitemapHash string) error {
+	return s.remove(indexesBucket, sitemapHash)
+}
+
+func (s *boltStore) Indexes() ([]*SitemapCacheIndex, error) {
+	var indexes []*SitemapCacheIndex
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(indexesBucket).ForEach(func(_, v []byte) error {
+			var index SitemapCacheIndex
+			if err := json.Unmarshal(v, &index); err == nil {
+				indexes = append(indexes, &index)
+			}
+			return nil
+		})
+	})
+	return indexes, err
+}
+
+func (s *boltStore) Clear() (int, error) {
+	cleared := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{entriesBucket, indexesBucket} {
+			cleared += tx.Bucket(name).Stats().KeyN
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+			if _, err := tx.CreateBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return cleared, nil
+}
+
+func (s *boltStore) Close() error {
+	return s.db.Close()
+}
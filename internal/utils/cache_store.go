@@ -0,0 +1,403 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/mattjh1/psi-map/internal/constants"
+	"github.com/mattjh1/psi-map/internal/logger"
+)
+
+// Cache storage backends
+const (
+	// CacheBackendFile keeps one JSON file per URL and per sitemap index
+	CacheBackendFile = "file"
+	// CacheBackendBolt keeps the whole cache in one bbolt database file
+	CacheBackendBolt = "bolt"
+)
+
+// CacheStore persists URL cache entries and sitemap indexes. Entry keys
+// look like "<url key>/<variant>.json", grouping every entry of a URL under
+// its URL key, and are the same in every backend so a cache moves between
+// them unchanged. Implementations are safe for concurrent use.
+type CacheStore interface {
+	// Backend returns the backend name, e.g. CacheBackendFile
+	Backend() string
+
+	LoadEntry(key string) (*URLCacheEntry, error)
+	SaveEntry(key string, entry *URLCacheEntry) error
+	DeleteEntry(key string) error
+	// EntrySize returns the stored size of the entry in bytes, 0 if unknown
+	EntrySize(key string) int64
+	// EntryKeys returns the keys of every entry stored for the URL key
+	EntryKeys(urlKey string) ([]string, error)
+	// ForEachEntry calls fn for every readable entry until fn returns an error
+	ForEachEntry(fn func(key string, entry *URLCacheEntry) error) error
+
+	LoadIndex(sitemapHash string) (*SitemapCacheIndex, bool)
+	SaveIndex(index *SitemapCacheIndex) error
+	DeleteIndex(sitemapHash string) error
+	// Indexes returns every readable sitemap index
+	Indexes() ([]*SitemapCacheIndex, error)
+
+	// Clear removes everything and returns how many entries and indexes it held
+	Clear() (int, error)
+	Close() error
+}
+
+// entryBatchSaver is implemented by stores that write many entries faster
+// in one go than one at a time
+type entryBatchSaver interface {
+	SaveEntries(entries map[string]*URLCacheEntry) error
+}
+
+var (
+	storesMu     sync.Mutex
+	stores       = make(map[storeKey]CacheStore)
+	cacheBackend = CacheBackendFile
+)
+
+// storeKey identifies an open store; the cache directory follows the
+// environment, so it is part of the key
+type storeKey struct {
+	backend string
+	dir     string
+}
+
+// ValidateCacheBackend checks that the backend name is supported
+func ValidateCacheBackend(backend string) error {
+	switch backend {
+	case CacheBackendFile, CacheBackendBolt:
+		return nil
+	default:
+		return fmt.Errorf("unsupported cache backend: %s (supported: %s, %s)", backend, CacheBackendFile, CacheBackendBolt)
+	}
+}
+
+// SetCacheBackend selects the backend used when none is named
+func SetCacheBackend(backend string) error {
+	if err := ValidateCacheBackend(backend); err != nil {
+		return err
+	}
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	cacheBackend = backend
+	return nil
+}
+
+// OpenCacheStore returns the store of the backend in the cache directory,
+// or of the selected backend when backend is empty. Each store is opened
+// once per process and shared; CloseCacheStores closes them.
+func OpenCacheStore(backend string) (CacheStore, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return nil, err
+	}
+
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	if backend == "" {
+		backend = cacheBackend
+	}
+	if err := ValidateCacheBackend(backend); err != nil {
+		return nil, err
+	}
+	key := storeKey{backend: backend, dir: dir}
+	if store, ok := stores[key]; ok {
+		return store, nil
+	}
+
+	var store CacheStore
+	switch backend {
+	case CacheBackendBolt:
+		store, err = openBoltStore(filepath.Join(dir, constants.CacheDBFile))
+	default:
+		store, err = openFileStore(dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	stores[key] = store
+	return store, nil
+}
+
+// isLegacyEntryKey reports whether an entry key predates per-strategy
+// entries, when each URL had a single "url-<hash>.json" entry. Those
+// entries say nothing about the strategy or profile, so no run can use them.
+func isLegacyEntryKey(key string) bool {
+	return strings.HasPrefix(key, "url-") && strings.HasSuffix(key, ".json") && !strings.Contains(key, "/")
+}
+
+// logLegacyEntries reports the entries removed because they predate
+// per-strategy entries
+func logLegacyEntries(removed int) {
+	if removed > 0 {
+		logger.GetLogger().Tagged("CACHE", "Removed %d cached result(s) stored by an older psi-map version", "🧹", removed)
+	}
+}
+
+// CloseCacheStores closes every store opened by OpenCacheStore
+func CloseCacheStores() error {
+	storesMu.Lock()
+	defer storesMu.Unlock()
+	var errs error
+	for key, store := range stores {
+		errs = errors.Join(errs, store.Close())
+		delete(stores, key)
+	}
+	return errs
+}
+
+// MigrateCache copies every entry and index of one backend to another and
+// returns how many of each were copied. The source is left untouched.
+func MigrateCache(from, to string) (entries, indexes int, err error) {
+	if from == to {
+		return 0, 0, fmt.Errorf("cache is already stored in the %s backend", to)
+	}
+	src, err := OpenCacheStore(from)
+	if err != nil {
+		return 0, 0, err
+	}
+	dst, err := OpenCacheStore(to)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	batch := make(map[string]*URLCacheEntry, constants.CacheMigrateBatch)
+	flush := func() error {
+		if err := saveEntries(dst, batch); err != nil {
+			return err
+		}
+		entries += len(batch)
+		clear(batch)
+		return nil
+	}
+	err = src.ForEachEntry(func(key string, entry *URLCacheEntry) error {
+		batch[key] = entry
+		if len(batch) < constants.CacheMigrateBatch {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return entries, 0, fmt.Errorf("failed to migrate cache entries: %w", err)
+	}
+
+	all, err := src.Indexes()
+	if err != nil {
+		return entries, 0, fmt.Errorf("failed to read sitemap indexes: %w", err)
+	}
+	for _, index := range all {
+		if err := dst.SaveIndex(index); err != nil {
+			return entries, indexes, fmt.Errorf("failed to migrate sitemap index %s: %w", index.SitemapHash, err)
+		}
+		indexes++
+	}
+	return entries, indexes, nil
+}
+
+// saveEntries writes the entries in one batch when the store supports it
+func saveEntries(store CacheStore, entries map[string]*URLCacheEntry) error {
+	if batcher, ok := store.(entryBatchSaver); ok {
+		return batcher.SaveEntries(entries)
+	}
+	for key, entry := range entries {
+		if err := store.SaveEntry(key, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fileStore keeps entries in urls/ and indexes in indexes/ under the cache
+// directory, one JSON file each
+type fileStore struct {
+	dir string
+}
+
+func openFileStore(dir string) (*fileStore, error) {
+	s := &fileStore{dir: dir}
+	if err := s.makeDirs(); err != nil {
+		return nil, err
+	}
+	removed, err := s.removeLegacyEntries()
+	if err != nil {
+		return nil, err
+	}
+	logLegacyEntries(removed)
+	return s, nil
+}
+
+// removeLegacyEntries deletes the flat urls/url-<hash>.json files of older
+// versions, which urlKeys never lists
+func (s *fileStore) removeLegacyEntries() (int, error) {
+	files, err := os.ReadDir(filepath.Join(s.dir, "urls"))
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, file := range files {
+		if file.IsDir() || !isLegacyEntryKey(file.Name()) {
+			continue
+		}
+		if err := os.Remove(s.entryPath(file.Name())); err != nil {
+			return removed, fmt.Errorf("failed to remove old cache entry %s: %w", file.Name(), err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+func (s *fileStore) makeDirs() error {
+	if err := os.MkdirAll(filepath.Join(s.dir, "urls"), constants.DefaultDirPermissions); err != nil {
+		return fmt.Errorf("failed to create URLs cache directory: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(s.dir, "indexes"), constants.DefaultDirPermissions); err != nil {
+		return fmt.Errorf("failed to create indexes cache directory: %v", err)
+	}
+	return nil
+}
+
+func (s *fileStore) Backend() string { return CacheBackendFile }
+
+func (s *fileStore) entryPath(key string) string {
+	return filepath.Join(s.dir, "urls", filepath.FromSlash(key))
+}
+
+func (s *fileStore) LoadEntry(key string) (*URLCacheEntry, error) {
+	return loadURLCacheEntry(s.entryPath(key))
+}
+
+func (s *fileStore) SaveEntry(key string, entry *URLCacheEntry) error {
+	path := s.entryPath(key)
+	if err := os.MkdirAll(filepath.Dir(path), constants.DefaultDirPermissions); err != nil {
+		return fmt.Errorf("failed to create URL cache directory: %v", err)
+	}
+	return saveURLCacheEntry(path, entry)
+}
+
+func (s *fileStore) DeleteEntry(key string) error {
+	return os.Remove(s.entryPath(key))
+}
+
+func (s *fileStore) EntrySize(key string) int64 {
+	info, err := os.Stat(s.entryPath(key))
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+func (s *fileStore) EntryKeys(urlKey string) ([]string, error) {
+	files, err := os.ReadDir(filepath.Join(s.dir, "urls", urlKey))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(files))
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), ".json") && !strings.HasPrefix(file.Name(), "tmp-") {
+			keys = append(keys, urlKey+"/"+file.Name())
+		}
+	}
+	return keys, nil
+}
+
+// urlKeys returns the URL keys that have entries
+func (s *fileStore) urlKeys() ([]string, error) {
+	dirs, err := os.ReadDir(filepath.Join(s.dir, "urls"))
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if dir.IsDir() && strings.HasPrefix(dir.Name(), "url-") {
+			keys = append(keys, dir.Name())
+		}
+	}
+	return keys, nil
+}
+
+func (s *fileStore) ForEachEntry(fn func(key string, entry *URLCacheEntry) error) error {
+	urlKeys, err := s.urlKeys()
+	if err != nil {
+		return err
+	}
+	for _, urlKey := range urlKeys {
+		keys, err := s.EntryKeys(urlKey)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			entry, err := s.LoadEntry(key)
+			if err != nil {
+				continue
+			}
+			if err := fn(key, entry); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *fileStore) LoadIndex(sitemapHash string) (*SitemapCacheIndex, bool) {
+	return loadSitemapIndex(getSitemapIndexFilename(s.dir, sitemapHash))
+}
+
+func (s *fileStore) SaveIndex(index *SitemapCacheIndex) error {
+	return saveSitemapIndex(getSitemapIndexFilename(s.dir, index.SitemapHash), index)
+}
+
+func (s *fileStore) DeleteIndex(sitemapHash string) error {
+	return os.Remove(getSitemapIndexFilename(s.dir, sitemapHash))
+}
+
+func (s *fileStore) Indexes() ([]*SitemapCacheIndex, error) {
+	files, err := os.ReadDir(filepath.Join(s.dir, "indexes"))
+	if err != nil {
+		return nil, err
+	}
+	indexes := make([]*SitemapCacheIndex, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || !strings.HasPrefix(file.Name(), "sitemap-") {
+			continue
+		}
+		if index, ok := loadSitemapIndex(filepath.Join(s.dir, "indexes", file.Name())); ok {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes, nil
+}
+
+func (s *fileStore) Clear() (int, error) {
+	cleared := 0
+	if urlKeys, err := s.urlKeys(); err == nil {
+		for _, urlKey := range urlKeys {
+			keys, _ := s.EntryKeys(urlKey)
+			cleared += len(keys)
+		}
+	}
+	if indexes, err := s.Indexes(); err == nil {
+		cleared += len(indexes)
+	}
+	for _, sub := range []string{"urls", "indexes"} {
+		dir := filepath.Join(s.dir, sub)
+		if err := os.RemoveAll(dir); err != nil {
+			return cleared, fmt.Errorf("failed to remove cache directory %s: %w", dir, err)
+		}
+	}
+	// The store stays usable for the rest of the process
+	return cleared, s.makeDirs()
+}
+
+func (s *fileStore) Close() error { return nil }
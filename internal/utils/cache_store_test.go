@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTempCache points the cache at a fresh directory for the test
+func useTempCache(t *testing.T) {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Cleanup(func() {
+		require.NoError(t, CloseCacheStores())
+		require.NoError(t, SetCacheBackend(CacheBackendFile))
+	})
+}
+
+func TestCacheStore_Backends(t *testing.T) {
+	for _, backend := range []string{CacheBackendFile, CacheBackendBolt} {
+		t.Run(backend, func(t *testing.T) {
+			useTempCache(t)
+			store, err := OpenCacheStore(backend)
+			require.NoError(t, err)
+			assert.Equal(t, backend, store.Backend())
+
+			again, err := OpenCacheStore(backend)
+			require.NoError(t, err)
+			assert.Same(t, store, again, "a store is opened once per process")
+
+			key := entryCacheKey("https://example.com/a", "mobile", DefaultCacheProfile().Fingerprint())
+			_, err = store.LoadEntry(key)
+			assert.Error(t, err)
+
+			entry := &URLCacheEntry{URL: "https://example.com/a", Timestamp: time.Now().Round(0)}
+			require.NoError(t, store.SaveEntry(key, entry))
+			loaded, err := store.LoadEntry(key)
+			require.NoError(t, err)
+			assert.Equal(t, entry.URL, loaded.URL)
+			assert.Positive(t, store.EntrySize(key))
+			keys, err := store.EntryKeys(urlCacheKey("https://example.com/a"))
+			require.NoError(t, err)
+			assert.Equal(t, []string{key}, keys)
+
+			index := &SitemapCacheIndex{SitemapHash: "abc", URLs: map[string]string{entry.URL: key}}
+			require.NoError(t, store.SaveIndex(index))
+			loadedIndex, ok := store.LoadIndex("abc")
+			require.True(t, ok)
+			assert.Equal(t, index.URLs, loadedIndex.URLs)
+			indexes, err := store.Indexes()
+			require.NoError(t, err)
+			assert.Len(t, indexes, 1)
+
+			keys = nil
+			require.NoError(t, store.ForEachEntry(func(k string, _ *URLCacheEntry) error {
+				keys = append(keys, k)
+				return nil
+			}))
+			assert.Equal(t, []string{key}, keys)
+
+			cleared, err := store.Clear()
+			require.NoError(t, err)
+			assert.Equal(t, 2, cleared)
+			_, ok = store.LoadIndex("abc")
+			assert.False(t, ok)
+
+			// Still usable after clearing
+			require.NoError(t, store.SaveEntry(key, entry))
+			require.NoError(t, store.DeleteEntry(key))
+			_, err = store.LoadEntry(key)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCacheStore_RemovesLegacyEntries(t *testing.T) {
+	for _, backend := range []string{CacheBackendFile, CacheBackendBolt} {
+		t.Run(backend, func(t *testing.T) {
+			useTempCache(t)
+			store, err := OpenCacheStore(backend)
+			require.NoError(t, err)
+			current := entryCacheKey("https://example.com/a", "mobile", DefaultCacheProfile().Fingerprint())
+			entry := &URLCacheEntry{URL: "https://example.com/a"}
+			require.NoError(t, store.SaveEntry(current, entry))
+
+			// One flat entry per URL, as stored before per-strategy entries
+			legacy := "url-c7208ac94afcd66b5d5cd1dc5fc49c8b.json"
+			switch s := store.(type) {
+			case *fileStore:
+				require.NoError(t, saveURLCacheEntry(filepath.Join(s.dir, "urls", legacy), entry))
+			case *boltStore:
+				require.NoError(t, s.put(entriesBucket, legacy, entry))
+			}
+			require.NoError(t, CloseCacheStores())
+
+			store, err = OpenCacheStore(backend)
+			require.NoError(t, err)
+			var keys []string
+			require.NoError(t, store.ForEachEntry(func(k string, _ *URLCacheEntry) error {
+				keys = append(keys, k)
+				return nil
+			}))
+			assert.Equal(t, []string{current}, keys)
+			_, err = store.LoadEntry(legacy)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMigrateCache_MovesResultsBetweenBackends(t *testing.T) {
+	useTempCache(t)
+	sitemap := "https://example.com/sitemap.xml"
+
+	cache, err := OpenURLCache(sitemap, nil, 24)
+	require.NoError(t, err)
+	for _, url := range []string{"https://example.com/a", "https://example.com/b"} {
+		require.NoError(t, cache.Save(&types.PageResult{URL: url, Mobile: &types.Result{}, Desktop: &types.Result{}}))
+	}
+	require.NoError(t, cache.Flush())
+
+	entries, indexes, err := MigrateCache(CacheBackendFile, CacheBackendBolt)
+	require.NoError(t, err)
+	assert.Equal(t, 4, entries, "one entry per strategy")
+	assert.Equal(t, 1, indexes)
+
+	require.NoError(t, SetCacheBackend(CacheBackendBolt))
+	migrated, err := OpenURLCache(sitemap, nil, 24)
+	require.NoError(t, err)
+	result, ok := migrated.Lookup("https://example.com/b")
+	require.True(t, ok)
+	assert.Equal(t, "https://example.com/b", result.URL)
+
+	infos, err := ListCacheFiles(24, true)
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, 2, infos[0].ValidCount)
+
+	_, _, err = MigrateCache(CacheBackendBolt, CacheBackendBolt)
+	assert.Error(t, err)
+}
+
+func TestSetCacheBackend_RejectsUnknown(t *testing.T) {
+	assert.Error(t, SetCacheBackend("sqlite"))
+	_, err := OpenCacheStore("sqlite")
+	assert.Error(t, err)
+}
@@ -2,11 +2,19 @@ package utils
 
 import (
 	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/mattjh1/psi-map/internal/constants"
 	"github.com/mattjh1/psi-map/internal/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCalculateSitemapHash(t *testing.T) {
@@ -23,20 +31,42 @@ func TestCalculateSitemapHash(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEqual(t, hash1, hash3, "Hashes for different URLs should be different")
 
-	// Test with sitemap path (mock file system if needed, for now, just ensure it doesn't error)
-	// This part would ideally involve mocking os.Open and io.Copy
-	// For now, we'll just test a non-existent path to ensure error handling (or lack thereof) is consistent
-	_, err = calculateSitemapHash("non_existent_sitemap.xml", []string{})
-	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "failed to open sitemap")
+	// Local sitemaps are keyed by path, so editing one keeps its index
+	sitemap := filepath.Join(t.TempDir(), "sitemap.xml")
+	require.NoError(t, os.WriteFile(sitemap, []byte("<urlset/>"), 0o600))
+	hash4, err := calculateSitemapHash(sitemap, nil)
+	assert.NoError(t, err)
+	require.NoError(t, os.WriteFile(sitemap, []byte("<urlset><url/></urlset>"), 0o600))
+	hash5, err := calculateSitemapHash(sitemap, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, hash4, hash5, "Hashes for the same sitemap path should be identical")
 }
 
-func TestGetURLCacheFilename(t *testing.T) {
-	cacheDir := "/tmp/test_cache"
+func TestCalculateSitemapHash_Remote(t *testing.T) {
+	hash1, err := calculateSitemapHash("https://example.com/sitemap.xml", []string{"http://example.com/1"})
+	assert.NoError(t, err)
+	hash2, err := calculateSitemapHash("https://example.com/sitemap.xml", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, hash1, hash2, "Remote inputs should be keyed by URL, not by their URL list")
+
+	hash3, err := calculateSitemapHash("https://example.org/sitemap.xml", nil)
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash1, hash3)
+}
+
+func TestEntryCacheKey(t *testing.T) {
 	url := "http://example.com/page"
+	profile := DefaultCacheProfile().Fingerprint()
 
-	filename := getURLCacheFilename(cacheDir, url)
-	assert.Equal(t, "/tmp/test_cache/urls/url-c7208ac94afcd66b5d5cd1dc5fc49c8b.json", filename)
+	key := entryCacheKey(url, "mobile", profile)
+	assert.Equal(t, "url-c7208ac94afcd66b5d5cd1dc5fc49c8b/mobile-"+profile+".json", key)
+	assert.NotEqual(t, key, entryCacheKey(url, "desktop", profile), "strategies are stored separately")
+
+	other := CacheProfile{Categories: []string{"performance"}, Source: CacheSourcePSI}.Fingerprint()
+	assert.NotEqual(t, profile, other, "other categories never share entries")
+	reordered := CacheProfile{Categories: []string{"seo", "performance", "best-practices", "accessibility"}, Source: CacheSourcePSI}
+	assert.Equal(t, profile, reordered.Fingerprint(), "category order does not matter")
+	assert.NotEqual(t, profile, CacheProfile{Categories: PSICategories, Source: "custom"}.Fingerprint())
 }
 
 func TestGetSitemapIndexFilename(t *testing.T) {
@@ -116,3 +146,241 @@ func TestFormatDuration(t *testing.T) {
 	assert.Equal(t, "1.0d", formatDuration(24*time.Hour))
 	assert.Equal(t, "2.5d", formatDuration(60*time.Hour))
 }
+
+func TestURLCache_SaveIsVisibleToNextRun(t *testing.T) {
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+	sitemap := "https://example.com/sitemap.xml"
+
+	cache, err := OpenURLCache(sitemap, nil, 24)
+	require.NoError(t, err)
+	_, ok := cache.Lookup("https://example.com/a")
+	assert.False(t, ok)
+
+	require.NoError(t, cache.Save(&types.PageResult{URL: "https://example.com/a", Mobile: &types.Result{}, Desktop: &types.Result{}}))
+	result, ok := cache.Lookup("https://example.com/a")
+	require.True(t, ok)
+	assert.Equal(t, "https://example.com/a", result.URL)
+
+	// A later run of the same sitemap resumes from the saved result
+	next, err := OpenURLCache(sitemap, nil, 24)
+	require.NoError(t, err)
+	_, ok = next.Lookup("https://example.com/a")
+	assert.True(t, ok)
+	_, ok = next.Lookup("https://example.com/b")
+	assert.False(t, ok)
+
+	// Each strategy is served on its own, but never under another profile
+	store, err := OpenCacheStore("")
+	require.NoError(t, err)
+	profile := DefaultCacheProfile()
+	profile.Strategies = []string{"mobile"}
+	mobileOnly, err := NewURLCache(store, profile, sitemap, nil, 24)
+	require.NoError(t, err)
+	result, ok = mobileOnly.Lookup("https://example.com/a")
+	require.True(t, ok)
+	assert.NotNil(t, result.Mobile)
+	assert.Nil(t, result.Desktop)
+
+	profile.Categories = []string{"performance"}
+	performanceOnly, err := NewURLCache(store, profile, sitemap, nil, 24)
+	require.NoError(t, err)
+	_, ok = performanceOnly.Lookup("https://example.com/a")
+	assert.False(t, ok)
+
+	// No temporary files are left behind
+	err = filepath.WalkDir(cacheHome, func(path string, d os.DirEntry, err error) error {
+		require.NoError(t, err)
+		assert.False(t, strings.HasPrefix(d.Name(), "tmp-"), path)
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+func TestURLCache_SharedAcrossInputs(t *testing.T) {
+	useTempCache(t)
+	url := "https://example.com/a"
+
+	blog, err := OpenURLCache("https://example.com/blog-sitemap.xml", nil, 24)
+	require.NoError(t, err)
+	require.NoError(t, blog.Save(&types.PageResult{URL: url, Mobile: &types.Result{}, Desktop: &types.Result{}}))
+	require.NoError(t, blog.Flush())
+
+	// Another input containing the URL reuses the result and lists it
+	site, err := OpenURLCache("https://example.com/sitemap.xml", nil, 24)
+	require.NoError(t, err)
+	result, ok := site.Lookup(url)
+	require.True(t, ok)
+	assert.Equal(t, url, result.URL)
+
+	// The index is written once per run, not once per URL
+	_, ok = site.store.LoadIndex(site.hash)
+	assert.False(t, ok)
+	require.NoError(t, site.Flush())
+	index, ok := site.store.LoadIndex(site.hash)
+	require.True(t, ok)
+	assert.Contains(t, index.URLs, url)
+
+	infos, err := ListCacheFiles(24, true)
+	require.NoError(t, err)
+	assert.Len(t, infos, 2, "each input keeps its own membership list")
+}
+
+func TestURLCache_FlushesIndexPeriodically(t *testing.T) {
+	useTempCache(t)
+	cache, err := OpenURLCache("https://example.com/sitemap.xml", nil, 24)
+	require.NoError(t, err)
+
+	for i := range constants.CacheIndexFlushEvery {
+		_, ok := cache.store.LoadIndex(cache.hash)
+		require.False(t, ok, "written after %d result(s)", i)
+		url := fmt.Sprintf("https://example.com/%d", i)
+		require.NoError(t, cache.Save(&types.PageResult{URL: url, Mobile: &types.Result{}, Desktop: &types.Result{}}))
+	}
+
+	// A crash from here on keeps every result saved so far listed
+	index, ok := cache.store.LoadIndex(cache.hash)
+	require.True(t, ok)
+	assert.Len(t, index.URLs, constants.CacheIndexFlushEvery)
+}
+
+func TestURLCache_ExpiredResultKeepsItsScoreChange(t *testing.T) {
+	useTempCache(t)
+	url := "https://example.com/a"
+	cache, err := OpenURLCache("https://example.com/sitemap.xml", nil, 24)
+	require.NoError(t, err)
+	cache.profile.Strategies = []string{"mobile"}
+	require.NoError(t, cache.Save(&types.PageResult{URL: url, Mobile: &types.Result{Scores: &types.CategoryScores{Performance: 90}}}))
+
+	key := entryCacheKey(url, "mobile", cache.fingerprint)
+	entry, err := cache.store.LoadEntry(key)
+	require.NoError(t, err)
+	entry.Timestamp = time.Now().Add(-48 * time.Hour)
+	require.NoError(t, cache.store.SaveEntry(key, entry))
+
+	// The expired entry stays until the new result replaces it
+	_, ok := cache.Lookup(url)
+	assert.False(t, ok)
+	require.NoError(t, cache.Save(&types.PageResult{URL: url, Mobile: &types.Result{Scores: &types.CategoryScores{Performance: 70}}}))
+	page, ok := cache.Page(url)
+	require.True(t, ok)
+	assert.InDelta(t, 20, page.Change, 0.001)
+}
+
+func TestURLCache_FailedStrategyExpiresOnItsOwn(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	url := "https://example.com/a"
+
+	cache, err := OpenURLCache("https://example.com/sitemap.xml", nil, 24)
+	require.NoError(t, err)
+	mobile := &types.Result{Scores: &types.CategoryScores{Performance: 90}}
+	require.NoError(t, cache.Save(&types.PageResult{URL: url, Mobile: mobile, Desktop: &types.Result{Error: fmt.Errorf("timeout")}}))
+	mobileEntry, err := cache.store.LoadEntry(entryCacheKey(url, "mobile", cache.fingerprint))
+	require.NoError(t, err)
+
+	// Within the failure TTL the failure is served like any result
+	_, ok := cache.Lookup(url)
+	assert.True(t, ok)
+
+	cache.FailureTTL = 0
+	page, ok := cache.Page(url)
+	require.True(t, ok)
+	assert.Equal(t, []string{"desktop"}, page.Expired)
+	_, ok = cache.Lookup(url)
+	assert.False(t, ok)
+
+	partial, missing := cache.Resume(url)
+	require.NotNil(t, partial)
+	assert.Equal(t, []string{"desktop"}, missing)
+	assert.NotNil(t, partial.Mobile)
+	assert.Nil(t, partial.Desktop)
+
+	// Saving the completed page leaves the reused mobile entry untouched
+	partial.Desktop = &types.Result{Scores: &types.CategoryScores{Performance: 70}}
+	require.NoError(t, cache.Save(partial))
+	again, err := cache.store.LoadEntry(entryCacheKey(url, "mobile", cache.fingerprint))
+	require.NoError(t, err)
+	assert.Equal(t, mobileEntry.Timestamp, again.Timestamp)
+	result, ok := cache.Lookup(url)
+	require.True(t, ok)
+	assert.NoError(t, result.Desktop.Error)
+
+	// Nothing to resume once every strategy is fresh
+	partial, _ = cache.Resume(url)
+	assert.Nil(t, partial)
+}
+
+func TestWriteJSONFile_ConcurrentWriters(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "entry.json")
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			entry := &URLCacheEntry{URL: fmt.Sprintf("https://example.com/%s", strings.Repeat("a", i*100))}
+			assert.NoError(t, writeJSONFile(filename, entry))
+		}()
+	}
+	wg.Wait()
+
+	_, err := loadURLCacheEntry(filename)
+	require.NoError(t, err, "the winning write is complete JSON")
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1, "no temporary files are left behind")
+}
+
+func TestCleanExpiredCacheFiles(t *testing.T) {
+	for _, backend := range []string{CacheBackendFile, CacheBackendBolt} {
+		t.Run(backend, func(t *testing.T) {
+			useTempCache(t)
+			require.NoError(t, SetCacheBackend(backend))
+			store, err := OpenCacheStore("")
+			require.NoError(t, err)
+			cache, err := NewURLCache(store, DefaultCacheProfile(), "https://example.com/sitemap.xml", nil, 24)
+			require.NoError(t, err)
+
+			now := time.Now()
+			save := func(url string, age time.Duration, failed bool) string {
+				entry := &URLCacheEntry{URL: url, Timestamp: now.Add(-age)}
+				if failed {
+					entry.Result.Error = fmt.Errorf("timeout")
+				}
+				key := entryCacheKey(url, "mobile", cache.fingerprint)
+				require.NoError(t, store.SaveEntry(key, entry))
+				return key
+			}
+			for _, url := range []string{"https://example.com/old", "https://example.com/failed", "https://example.com/fresh"} {
+				require.NoError(t, cache.Save(&types.PageResult{URL: url, Mobile: &types.Result{}}))
+			}
+			require.NoError(t, cache.Flush())
+			old := save("https://example.com/old", 48*time.Hour, false)
+			failed := save("https://example.com/failed", 2*time.Hour, true)
+			fresh := save("https://example.com/fresh", 2*time.Hour, false)
+			orphan := save("https://example.com/unlisted", 48*time.Hour, false)
+
+			removed, err := CleanExpiredCacheFiles(24, true)
+			require.NoError(t, err)
+			assert.Equal(t, 3, removed, "a dry run counts the entries it would remove")
+			_, err = store.LoadEntry(old)
+			assert.NoError(t, err, "a dry run removes nothing")
+
+			removed, err = CleanExpiredCacheFiles(24, false)
+			require.NoError(t, err)
+			assert.Equal(t, 3, removed)
+			for _, key := range []string{old, failed, orphan} {
+				_, err := store.LoadEntry(key)
+				assert.Error(t, err, key)
+			}
+			_, err = store.LoadEntry(fresh)
+			assert.NoError(t, err)
+
+			indexes, err := store.Indexes()
+			require.NoError(t, err)
+			require.Len(t, indexes, 1)
+			assert.Equal(t, []string{"https://example.com/fresh"}, slices.Collect(maps.Keys(indexes[0].URLs)))
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/mattjh1/psi-map/internal/constants"
+)
+
+// ConcurrencyLimit caps how many URLs are analyzed at once. A fixed limit
+// never changes. An adaptive one follows AIMD: it grows by one after a full
+// window of healthy requests and halves when PSI rate limits. It is safe for
+// concurrent use.
+type ConcurrencyLimit struct {
+	mu       sync.Mutex
+	wake     chan struct{} // closed when a slot may have opened
+	limit    int
+	min, max int
+	inFlight int
+	adaptive bool
+
+	healthy      int           // healthy requests since the last change
+	latency      time.Duration // smoothed request latency
+	baseline     time.Duration // fastest smoothed latency seen
+	lastDecrease time.Time
+	now          func() time.Time
+}
+
+// NewFixedLimit returns a limit of n URLs at once
+func NewFixedLimit(n int) *ConcurrencyLimit {
+	n = max(1, n)
+	return &ConcurrencyLimit{wake: make(chan struct{}), limit: n, min: n, max: n, now: time.Now}
+}
+
+// NewAdaptiveLimit returns a limit that starts at start and moves between 1
+// and ceiling as PSI responds
+func NewAdaptiveLimit(start, ceiling int) *ConcurrencyLimit {
+	ceiling = max(1, ceiling)
+	return &ConcurrencyLimit{
+		wake:     make(chan struct{}),
+		limit:    min(max(1, start), ceiling),
+		min:      1,
+		max:      ceiling,
+		adaptive: true,
+		now:      time.Now,
+	}
+}
+
+// Acquire waits for a free slot. It returns false when ctx is done first.
+func (l *ConcurrencyLimit) Acquire(ctx context.Context) bool {
+	for {
+		l.mu.Lock()
+		if l.inFlight < l.limit {
+			l.inFlight++
+			l.mu.Unlock()
+			return true
+		}
+		wake := l.wake
+		l.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// Release frees a slot taken by Acquire
+func (l *ConcurrencyLimit) Release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.inFlight--
+	l.notify()
+}
+
+// Limit returns the current number of URLs allowed at once
+func (l *ConcurrencyLimit) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// Observe feeds the outcome of one PSI request to an adaptive limit. It
+// returns the limit before and after the request was taken into account and,
+// when the limit changed, why; the reason is empty otherwise. Failures that
+// say nothing about PSI's health, such as an invalid URL, are ignored.
+func (l *ConcurrencyLimit) Observe(elapsed time.Duration, err error) (previous, limit int, reason string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	previous = l.limit
+	limit, reason = l.observe(elapsed, err)
+	return previous, limit, reason
+}
+
+// observe updates the limit. The caller holds the lock.
+func (l *ConcurrencyLimit) observe(elapsed time.Duration, err error) (int, string) {
+	if !l.adaptive {
+		return l.limit, ""
+	}
+
+	if status := throttleStatus(err); status != 0 {
+		l.healthy = 0
+		cooldown := max(l.latency, constants.AdaptiveMinCooldown)
+		if l.limit == l.min || l.now().Sub(l.lastDecrease) < cooldown {
+			return l.limit, ""
+		}
+		l.limit = max(l.min, l.limit/2)
+		l.lastDecrease = l.now()
+		return l.limit, fmt.Sprintf("rate limited (status %d)", status)
+	}
+	if err != nil {
+		if IsRetryable(err) {
+			l.healthy = 0
+		}
+		return l.limit, ""
+	}
+
+	if l.latency == 0 {
+		l.latency = elapsed
+	} else {
+		l.latency += time.Duration(constants.AdaptiveLatencySmoothing * float64(elapsed-l.latency))
+	}
+	if l.baseline == 0 || l.latency < l.baseline {
+		l.baseline = l.latency
+	}
+	if float64(l.latency) > constants.AdaptiveLatencyTolerance*float64(l.baseline) {
+		l.healthy = 0
+		return l.limit, ""
+	}
+
+	l.healthy++
+	if l.healthy < l.limit || l.limit == l.max {
+		return l.limit, ""
+	}
+	l.healthy = 0
+	l.limit++
+	l.notify()
+	return l.limit, fmt.Sprintf("healthy at %s per request", l.latency.Round(time.Millisecond))
+}
+
+// notify wakes every waiting Acquire. The caller holds the lock.
+func (l *ConcurrencyLimit) notify() {
+	close(l.wake)
+	l.wake = make(chan struct{})
+}
+
+// throttleStatus returns the status code when PSI asked to slow down
+func throttleStatus(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode == http.StatusServiceUnavailable) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
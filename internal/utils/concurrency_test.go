@@ -0,0 +1,103 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdaptiveLimit_GrowsAfterHealthyWindow(t *testing.T) {
+	l := NewAdaptiveLimit(2, 4)
+
+	_, _, reason := l.Observe(time.Second, nil)
+	assert.Empty(t, reason)
+	_, limit, reason := l.Observe(time.Second, nil)
+	assert.Equal(t, 3, limit)
+	assert.NotEmpty(t, reason)
+
+	// The next window is as long as the new limit
+	for range 2 {
+		_, _, reason = l.Observe(time.Second, nil)
+		assert.Empty(t, reason)
+	}
+	_, limit, _ = l.Observe(time.Second, nil)
+	assert.Equal(t, 4, limit)
+
+	// Never beyond the ceiling
+	for range 10 {
+		_, limit, _ = l.Observe(time.Second, nil)
+	}
+	assert.Equal(t, 4, limit)
+}
+
+func TestAdaptiveLimit_HalvesOnRateLimit(t *testing.T) {
+	now := time.Now()
+	l := NewAdaptiveLimit(8, 8)
+	l.now = func() time.Time { return now }
+
+	previous, limit, reason := l.Observe(time.Second, &APIError{StatusCode: http.StatusTooManyRequests})
+	assert.Equal(t, 8, previous)
+	assert.Equal(t, 4, limit)
+	assert.Contains(t, reason, "429")
+
+	// Requests already in flight do not halve it again right away
+	_, limit, reason = l.Observe(time.Second, &APIError{StatusCode: http.StatusServiceUnavailable})
+	assert.Equal(t, 4, limit)
+	assert.Empty(t, reason)
+
+	now = now.Add(time.Minute)
+	_, limit, _ = l.Observe(time.Second, &APIError{StatusCode: http.StatusServiceUnavailable})
+	assert.Equal(t, 2, limit)
+}
+
+func TestAdaptiveLimit_HoldsWhenSlowOrFailing(t *testing.T) {
+	l := NewAdaptiveLimit(1, 4)
+	l.Observe(time.Second, nil) // sets the baseline and grows to 2
+	assert.Equal(t, 2, l.Limit())
+
+	// Latency far above the baseline stops growth
+	for range 10 {
+		l.Observe(20*time.Second, nil)
+	}
+	assert.Equal(t, 2, l.Limit())
+
+	// Page errors say nothing about PSI and are ignored
+	l2 := NewAdaptiveLimit(1, 4)
+	_, _, reason := l2.Observe(0, &APIError{StatusCode: http.StatusBadRequest})
+	assert.Empty(t, reason)
+	assert.Equal(t, 1, l2.Limit())
+	_, _, reason = l2.Observe(0, errors.New("invalid URL"))
+	assert.Empty(t, reason)
+}
+
+func TestFixedLimit_NeverChanges(t *testing.T) {
+	l := NewFixedLimit(3)
+	for range 10 {
+		l.Observe(time.Second, nil)
+	}
+	l.Observe(time.Second, &APIError{StatusCode: http.StatusTooManyRequests})
+	assert.Equal(t, 3, l.Limit())
+}
+
+func TestConcurrencyLimit_AcquireWaitsForSlot(t *testing.T) {
+	l := NewFixedLimit(1)
+	assert.True(t, l.Acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.False(t, l.Acquire(ctx), "no slot while the only one is taken")
+
+	done := make(chan bool)
+	go func() { done <- l.Acquire(context.Background()) }()
+	l.Release()
+	select {
+	case ok := <-done:
+		assert.True(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("Acquire did not wake after Release")
+	}
+}
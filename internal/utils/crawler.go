@@ -0,0 +1,397 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/mattjh1/psi-map/internal/constants"
+	"github.com/mattjh1/psi-map/internal/logger"
+	"github.com/mattjh1/psi-map/internal/types"
+	"golang.org/x/net/html"
+)
+
+// crawlClient is the HTTP client used for crawling, replaceable in tests
+var crawlClient = &http.Client{Timeout: constants.CrawlRequestTimeout}
+
+// crawledPage holds the outcome of fetching a single page during a crawl
+type crawledPage struct {
+	URL      string
+	IsHTML   bool
+	Links    []string
+	NoFollow bool
+}
+
+// Crawl discovers same-origin HTML pages by following links from the start URL.
+// Pages are visited breadth-first until the depth or page limit is reached.
+// Once ctx is cancelled, requests in flight are stopped and ctx's error is
+// returned.
+func Crawl(ctx context.Context, cfg *types.CrawlConfig) ([]string, error) {
+	log := logger.GetLogger()
+
+	start, err := url.Parse(cfg.StartURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid crawl URL: %w", err)
+	}
+	if start.Scheme != "http" && start.Scheme != "https" {
+		return nil, fmt.Errorf("unsupported crawl URL scheme: %s", start.Scheme)
+	}
+	start.Fragment = ""
+
+	filter, err := NewURLFilter(cfg.Include, cfg.Exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	var robots *robotsRules
+	if !cfg.IgnoreRobots {
+		robots = fetchRobots(ctx, start)
+		if !robots.Allowed(start) {
+			return nil, fmt.Errorf("start URL is disallowed by robots.txt: %s", start)
+		}
+	}
+
+	maxPages := cfg.MaxPages
+	if maxPages <= 0 {
+		maxPages = constants.DefaultCrawlMaxPages
+	}
+	concurrency := max(1, cfg.Concurrency)
+
+	visited := map[string]bool{start.String(): true}
+	frontier := []string{start.String()}
+	discovered := make([]string, 0)
+	reported := make(map[string]bool)
+	fetched := 0
+
+	for depth := 0; depth <= cfg.MaxDepth && len(frontier) > 0 && fetched < maxPages; depth++ {
+		if remaining := maxPages - fetched; len(frontier) > remaining {
+			frontier = frontier[:remaining]
+		}
+		log.Tagged("CRAWL", "Depth %d: fetching %d page(s)", "🕷️", depth, len(frontier))
+
+		pages := fetchPages(ctx, frontier, concurrency)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		fetched += len(frontier)
+
+		next := make([]string, 0)
+		for _, page := range pages {
+			// Redirects can land several frontier URLs on the same page
+			if page == nil || !page.IsHTML || reported[page.URL] {
+				continue
+			}
+			reported[page.URL] = true
+			visited[page.URL] = true
+
+			if keep, _ := filter.Match(page.URL); keep {
+				discovered = append(discovered, page.URL)
+			}
+			if page.NoFollow || depth == cfg.MaxDepth {
+				continue
+			}
+			for _, link := range page.Links {
+				linkURL, err := url.Parse(link)
+				if err != nil || !sameOrigin(start, linkURL) || visited[link] {
+					continue
+				}
+				visited[link] = true
+				if robots != nil && !robots.Allowed(linkURL) {
+					continue
+				}
+				next = append(next, link)
+			}
+		}
+		frontier = next
+	}
+
+	log.Tagged("CRAWL", "Discovered %d page(s) from %d fetched", "🕸️", len(discovered), fetched)
+	return discovered, nil
+}
+
+// fetchPages fetches the given URLs with limited concurrency, preserving order.
+// URLs still waiting for a slot are skipped once ctx is cancelled.
+func fetchPages(ctx context.Context, urls []string, concurrency int) []*crawledPage {
+	var wg sync.WaitGroup
+	pages := make([]*crawledPage, len(urls))
+	sem := make(chan struct{}, concurrency)
+
+	for i, pageURL := range urls {
+		wg.Add(1)
+		go func(i int, pageURL string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			page, err := fetchPage(ctx, pageURL)
+			if err != nil {
+				logger.GetLogger().Debug("Crawl fetch failed for %s: %v", pageURL, err)
+				return
+			}
+			pages[i] = page
+		}(i, pageURL)
+	}
+
+	wg.Wait()
+	return pages
+}
+
+// fetchPage retrieves a page and extracts its outgoing links when it is HTML
+func fetchPage(ctx context.Context, pageURL string) (*crawledPage, error) {
+	ctx, cancel := context.WithTimeout(ctx, constants.CrawlRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", constants.CrawlUserAgent)
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := crawlClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("non-200 status: %d", resp.StatusCode)
+	}
+
+	page := &crawledPage{URL: pageURL, IsHTML: isHTMLContentType(resp.Header.Get("Content-Type"))}
+	if !page.IsHTML {
+		return page, nil
+	}
+
+	// Follow the final URL after redirects so relative links resolve correctly
+	base := resp.Request.URL
+	if start, err := url.Parse(pageURL); err == nil && !sameOrigin(start, base) {
+		return nil, fmt.Errorf("redirected off-origin to %s", base)
+	}
+	page.URL = stripFragment(base)
+	page.Links, page.NoFollow = extractLinks(io.LimitReader(resp.Body, constants.CrawlMaxBodyBytes), base)
+	return page, nil
+}
+
+// extractLinks returns the absolute, followable links of an HTML document and
+// whether the document asks crawlers not to follow any of its links
+func extractLinks(body io.Reader, base *url.URL) (links []string, noFollow bool) {
+	tokenizer := html.NewTokenizer(body)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return links, noFollow
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := tokenizer.Token()
+			switch token.Data {
+			case "base":
+				if href := attr(token, "href"); href != "" {
+					if resolved, err := base.Parse(href); err == nil {
+						base = resolved
+					}
+				}
+			case "meta":
+				if strings.EqualFold(attr(token, "name"), "robots") && hasToken(attr(token, "content"), "nofollow") {
+					noFollow = true
+				}
+			case "a", "area":
+				if hasToken(attr(token, "rel"), "nofollow") {
+					continue
+				}
+				href := strings.TrimSpace(attr(token, "href"))
+				if href == "" {
+					continue
+				}
+				resolved, err := base.Parse(href)
+				if err != nil || (resolved.Scheme != "http" && resolved.Scheme != "https") {
+					continue
+				}
+				links = append(links, stripFragment(resolved))
+			}
+		}
+	}
+}
+
+// attr returns the value of the named attribute of a token
+func attr(token html.Token, name string) string {
+	for _, a := range token.Attr {
+		if strings.EqualFold(a.Key, name) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// hasToken reports whether a space or comma separated list contains the token
+func hasToken(list, token string) bool {
+	for _, field := range strings.FieldsFunc(list, func(r rune) bool { return r == ' ' || r == ',' }) {
+		if strings.EqualFold(strings.TrimSpace(field), token) {
+			return true
+		}
+	}
+	return false
+}
+
+func isHTMLContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+func sameOrigin(a, b *url.URL) bool {
+	return strings.EqualFold(a.Scheme, b.Scheme) && strings.EqualFold(a.Host, b.Host)
+}
+
+func stripFragment(u *url.URL) string {
+	clean := *u
+	clean.Fragment = ""
+	clean.RawFragment = ""
+	return clean.String()
+}
+
+// robotsRule is a single Allow or Disallow line from robots.txt
+type robotsRule struct {
+	Pattern string
+	Allow   bool
+}
+
+// robotsRules holds the robots.txt rules that apply to the crawler's user agent
+type robotsRules struct {
+	Rules []robotsRule
+}
+
+// fetchRobots retrieves and parses robots.txt for the origin of the given URL.
+// A missing or unreadable robots.txt allows everything.
+func fetchRobots(ctx context.Context, origin *url.URL) *robotsRules {
+	robotsURL := &url.URL{Scheme: origin.Scheme, Host: origin.Host, Path: "/robots.txt"}
+
+	ctx, cancel := context.WithTimeout(ctx, constants.CrawlRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL.String(), http.NoBody)
+	if err != nil {
+		return &robotsRules{}
+	}
+	req.Header.Set("User-Agent", constants.CrawlUserAgent)
+
+	resp, err := crawlClient.Do(req)
+	if err != nil {
+		return &robotsRules{}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &robotsRules{}
+	}
+	return parseRobots(io.LimitReader(resp.Body, constants.CrawlMaxBodyBytes), constants.CrawlUserAgent)
+}
+
+// parseRobots extracts the rules for the given user agent, falling back to the
+// wildcard group when no group names the agent explicitly
+func parseRobots(r io.Reader, agent string) *robotsRules {
+	var specific, wildcard []robotsRule
+	var groupAgents []string
+	inRules := false
+	haveSpecific := false
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if inRules {
+				groupAgents = nil
+				inRules = false
+			}
+			groupAgents = append(groupAgents, strings.ToLower(value))
+		case "allow", "disallow":
+			inRules = true
+			if value == "" {
+				continue // An empty Disallow allows everything
+			}
+			rule := robotsRule{Pattern: value, Allow: key == "allow"}
+			for _, ua := range groupAgents {
+				switch {
+				case ua == "*":
+					wildcard = append(wildcard, rule)
+				case strings.HasPrefix(strings.ToLower(agent), ua):
+					specific = append(specific, rule)
+					haveSpecific = true
+				}
+			}
+		}
+	}
+
+	if haveSpecific {
+		return &robotsRules{Rules: specific}
+	}
+	return &robotsRules{Rules: wildcard}
+}
+
+// Allowed reports whether the URL may be crawled. The longest matching rule
+// wins, and Allow wins over Disallow when both match with equal length.
+func (r *robotsRules) Allowed(u *url.URL) bool {
+	if r == nil {
+		return true
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	allowed, matchLen := true, -1
+	for _, rule := range r.Rules {
+		if !robotsPatternMatch(rule.Pattern, path) {
+			continue
+		}
+		if l := len(rule.Pattern); l > matchLen || (l == matchLen && rule.Allow) {
+			allowed, matchLen = rule.Allow, l
+		}
+	}
+	return allowed
+}
+
+// robotsPatternMatch matches a robots.txt path pattern supporting * and $
+func robotsPatternMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(path)
+}
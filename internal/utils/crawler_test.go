@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestSite serves a small site with the given pages and robots.txt
+func newTestSite(t *testing.T, pages map[string]string, robots string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			if robots == "" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write([]byte(robots))
+			return
+		}
+		if r.URL.Path == "/file.pdf" {
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF"))
+			return
+		}
+		body, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testSitePages() map[string]string {
+	return map[string]string{
+		"/": `<html><body>
+			<a href="/about">About</a>
+			<a href="/blog/">Blog</a>
+			<a href="/file.pdf">PDF</a>
+			<a href="https://other.example.com/">External</a>
+			<a href="/private" rel="nofollow">Private</a>
+			<a href="mailto:me@example.com">Mail</a>
+			<a href="/about#team">Team</a>
+		</body></html>`,
+		"/about":       `<html><body><a href="/">Home</a></body></html>`,
+		"/blog/":       `<html><body><a href="post-1">Post 1</a><a href="/blog/post-2">Post 2</a></body></html>`,
+		"/blog/post-1": `<html><body><a href="/deep">Deep</a></body></html>`,
+		"/blog/post-2": `<html><head><meta name="robots" content="noindex, nofollow"></head><body><a href="/hidden">Hidden</a></body></html>`,
+		"/private":     `<html><body>Private</body></html>`,
+		"/deep":        `<html><body>Deep</body></html>`,
+		"/hidden":      `<html><body>Hidden</body></html>`,
+	}
+}
+
+func crawlPaths(t *testing.T, serverURL string, urls []string) []string {
+	t.Helper()
+	paths := make([]string, 0, len(urls))
+	for _, u := range urls {
+		require.True(t, strings.HasPrefix(u, serverURL), "unexpected off-origin URL %s", u)
+		paths = append(paths, strings.TrimPrefix(u, serverURL))
+	}
+	return paths
+}
+
+func TestCrawl_FollowsSameOriginLinks(t *testing.T) {
+	server := newTestSite(t, testSitePages(), "")
+
+	urls, err := Crawl(context.Background(), &types.CrawlConfig{StartURL: server.URL + "/", MaxDepth: 3, MaxPages: 50, Concurrency: 2})
+	require.NoError(t, err)
+
+	paths := crawlPaths(t, server.URL, urls)
+	assert.ElementsMatch(t, []string{"/", "/about", "/blog/", "/blog/post-1", "/blog/post-2", "/deep"}, paths)
+	assert.NotContains(t, paths, "/private", "rel=nofollow links should not be followed")
+	assert.NotContains(t, paths, "/hidden", "links on meta nofollow pages should not be followed")
+	assert.NotContains(t, paths, "/file.pdf", "non-HTML content should not be reported")
+}
+
+func TestCrawl_DepthAndPageLimits(t *testing.T) {
+	server := newTestSite(t, testSitePages(), "")
+
+	urls, err := Crawl(context.Background(), &types.CrawlConfig{StartURL: server.URL + "/", MaxDepth: 1, MaxPages: 50, Concurrency: 1})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"/", "/about", "/blog/"}, crawlPaths(t, server.URL, urls))
+
+	urls, err = Crawl(context.Background(), &types.CrawlConfig{StartURL: server.URL + "/", MaxDepth: 3, MaxPages: 2, Concurrency: 1})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(urls), 2)
+}
+
+func TestCrawl_IncludeExcludePatterns(t *testing.T) {
+	server := newTestSite(t, testSitePages(), "")
+
+	urls, err := Crawl(context.Background(), &types.CrawlConfig{
+		StartURL:    server.URL + "/",
+		MaxDepth:    3,
+		MaxPages:    50,
+		Concurrency: 2,
+		Include:     []string{"/blog/**"},
+		Exclude:     []string{"re:post-2$"},
+	})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"/blog/", "/blog/post-1"}, crawlPaths(t, server.URL, urls))
+
+	_, err = Crawl(context.Background(), &types.CrawlConfig{StartURL: server.URL, Include: []string{"re:("}})
+	assert.Error(t, err)
+}
+
+func TestCrawl_RespectsRobots(t *testing.T) {
+	robots := "User-agent: *\nDisallow: /blog/\nAllow: /blog/post-1\n"
+	server := newTestSite(t, testSitePages(), robots)
+
+	urls, err := Crawl(context.Background(), &types.CrawlConfig{StartURL: server.URL + "/", MaxDepth: 3, MaxPages: 50, Concurrency: 2})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"/", "/about"}, crawlPaths(t, server.URL, urls))
+
+	urls, err = Crawl(context.Background(), &types.CrawlConfig{StartURL: server.URL + "/", MaxDepth: 3, MaxPages: 50, Concurrency: 2, IgnoreRobots: true})
+	require.NoError(t, err)
+	assert.Contains(t, crawlPaths(t, server.URL, urls), "/blog/")
+}
+
+func TestCrawl_InvalidStartURL(t *testing.T) {
+	_, err := Crawl(context.Background(), &types.CrawlConfig{StartURL: "ftp://example.com"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported crawl URL scheme")
+}
+
+func TestCrawl_StopsWhenCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done() // never answers
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	start := time.Now()
+	_, err := Crawl(ctx, &types.CrawlConfig{StartURL: server.URL + "/", MaxDepth: 1, Concurrency: 1, IgnoreRobots: true})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestParseRobots(t *testing.T) {
+	robots := `
+# comment
+User-agent: googlebot
+Disallow: /
+
+User-agent: *
+Disallow: /admin
+Disallow: /*.json$
+Allow: /admin/public
+
+User-agent: psi-map
+Disallow: /reports
+`
+	rules := parseRobots(strings.NewReader(robots), "psi-map")
+	check := func(path string) bool {
+		u, err := url.Parse("https://example.com" + path)
+		require.NoError(t, err)
+		return rules.Allowed(u)
+	}
+	assert.False(t, check("/reports/2024"), "agent-specific group should apply")
+	assert.True(t, check("/admin"), "wildcard group should be ignored when a specific group exists")
+
+	rules = parseRobots(strings.NewReader(robots), "other-bot")
+	assert.False(t, check("/admin/settings"))
+	assert.True(t, check("/admin/public/page"), "longer Allow rule should win")
+	assert.False(t, check("/data/file.json"))
+	assert.True(t, check("/data/file.json?x=1"), "$ should anchor the pattern")
+	assert.True(t, check("/"))
+}
+
+func TestExtractLinks(t *testing.T) {
+	base, err := url.Parse("https://example.com/docs/")
+	require.NoError(t, err)
+
+	doc := `<html><head><base href="/root/"></head><body>
+		<a href="a">A</a>
+		<a href="https://example.com/b#frag">B</a>
+		<a href="c" rel="external nofollow">C</a>
+		<a href="javascript:void(0)">JS</a>
+		<a>Empty</a>
+	</body></html>`
+
+	links, noFollow := extractLinks(strings.NewReader(doc), base)
+	assert.False(t, noFollow)
+	assert.Equal(t, []string{"https://example.com/root/a", "https://example.com/b"}, links)
+}
+
+func TestCrawl_DeduplicatesRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<a href="/old">Old</a><a href="/new">New</a>`)
+	})
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `New`)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	urls, err := Crawl(context.Background(), &types.CrawlConfig{StartURL: server.URL + "/", MaxDepth: 1, MaxPages: 10, Concurrency: 1, IgnoreRobots: true})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"/", "/new"}, crawlPaths(t, server.URL, urls))
+}
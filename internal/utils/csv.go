@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/mattjh1/psi-map/internal/utils/validate"
+)
+
+// csvHeader lists one column per score and core metric for both strategies
+var csvHeader = []string{
+	"url", "original_url", "site", "duration_ms",
+	"mobile_performance", "mobile_accessibility", "mobile_best_practices", "mobile_seo",
+	"mobile_lcp_ms", "mobile_cls", "mobile_tbt_ms", "mobile_error",
+	"desktop_performance", "desktop_accessibility", "desktop_best_practices", "desktop_seo",
+	"desktop_lcp_ms", "desktop_cls", "desktop_tbt_ms", "desktop_error",
+}
+
+// SaveCSVReport writes one row per page with the scores and core metrics of
+// both strategies, for spreadsheets and BI tools
+func SaveCSVReport(report *types.ReportData, filename string) error {
+	components := validate.SplitFilePath(filename)
+	file, _, err := validate.SafeCreateFile(components.Dir, components.Name, components.Extension)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+	defer file.Close()
+
+	w := csv.NewWriter(file)
+	if err := w.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to save CSV report %s: %w", filename, err)
+	}
+	for _, result := range report.Results {
+		if result == nil {
+			continue
+		}
+		row := []string{result.URL, result.OriginalURL, result.Site, strconv.FormatInt(result.Duration.Milliseconds(), 10)}
+		row = append(row, csvStrategyColumns(result.Mobile)...)
+		row = append(row, csvStrategyColumns(result.Desktop)...)
+		if err := w.Write(row); err != nil {
+			return fmt.Errorf("failed to save CSV report %s: %w", filename, err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to save CSV report %s: %w", filename, err)
+	}
+	return nil
+}
+
+// csvStrategyColumns returns the score, metric and error columns of one
+// strategy, left empty when not available
+func csvStrategyColumns(result *types.Result) []string {
+	var scores [4]string
+	var metrics [3]string
+	var errText string
+	if result != nil && result.Error != nil {
+		errText = result.Error.Error()
+	}
+	if result != nil && result.Error == nil {
+		if s := result.Scores; s != nil {
+			scores = [4]string{
+				formatCSVNumber(s.Performance),
+				formatCSVNumber(s.Accessibility),
+				formatCSVNumber(s.BestPractices),
+				formatCSVNumber(s.SEO),
+			}
+		}
+		if m := result.Metrics; m != nil {
+			metrics = [3]string{
+				formatCSVNumber(m.LargestContentfulPaint),
+				formatCSVNumber(m.CumulativeLayoutShift),
+				formatCSVNumber(m.TotalBlockingTime),
+			}
+		}
+	}
+	columns := make([]string, 0, len(scores)+len(metrics)+1)
+	columns = append(columns, scores[:]...)
+	columns = append(columns, metrics[:]...)
+	return append(columns, errText)
+}
+
+func formatCSVNumber(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
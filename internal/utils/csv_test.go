@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveCSVReport(t *testing.T) {
+	page := mergeTestPage("https://example.com/", 72.5)
+	page.Duration = 1500 * time.Millisecond
+	page.Mobile.Metrics = &types.Metrics{LargestContentfulPaint: 2400, CumulativeLayoutShift: 0.05, TotalBlockingTime: 120}
+	page.Desktop = &types.Result{Strategy: "desktop", Error: errors.New("API error: status 500")}
+
+	path := filepath.Join(t.TempDir(), "report.csv")
+	require.NoError(t, SaveCSVReport(&types.ReportData{Results: []*types.PageResult{page}}, path))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	require.NoError(t, err)
+
+	require.Len(t, rows, 2)
+	assert.Equal(t, csvHeader, rows[0])
+	row := make(map[string]string, len(csvHeader))
+	for i, column := range csvHeader {
+		row[column] = rows[1][i]
+	}
+	assert.Equal(t, "https://example.com/", row["url"])
+	assert.Equal(t, "1500", row["duration_ms"])
+	assert.Equal(t, "72.5", row["mobile_performance"])
+	assert.Equal(t, "2400", row["mobile_lcp_ms"])
+	assert.Equal(t, "0.05", row["mobile_cls"])
+	assert.Empty(t, row["mobile_error"])
+	assert.Empty(t, row["desktop_performance"])
+	assert.Equal(t, "API error: status 500", row["desktop_error"])
+}
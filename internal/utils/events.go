@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/mattjh1/psi-map/internal/utils/validate"
+)
+
+// EventLog writes run events to a file as JSON lines, one event per line as
+// it happens, so other tools can follow a run with tail -f
+type EventLog struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+	path string
+	err  error
+}
+
+// OpenEventLog creates the event log. Paths without an extension get .jsonl.
+func OpenEventLog(path string) (*EventLog, error) {
+	components := validate.SplitFilePath(path)
+	if components.Extension == "" {
+		components.Extension = ".jsonl"
+	}
+	file, savedPath, err := validate.SafeCreateFile(components.Dir, components.Name, components.Extension)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event log: %w", err)
+	}
+	return &EventLog{file: file, enc: json.NewEncoder(file), path: savedPath}, nil
+}
+
+// Path returns the file the events are written to
+func (l *EventLog) Path() string {
+	return l.path
+}
+
+// Write appends one event. The first write error stops the log and is
+// returned by Close.
+func (l *EventLog) Write(e types.Event) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.err != nil {
+		return
+	}
+	if err := l.enc.Encode(e); err != nil {
+		l.err = fmt.Errorf("failed to write event log %s: %w", l.path, err)
+	}
+}
+
+// Close closes the file and reports the first write error, if any
+func (l *EventLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.file.Close(); err != nil && l.err == nil {
+		l.err = fmt.Errorf("failed to close event log %s: %w", l.path, err)
+	}
+	return l.err
+}
@@ -0,0 +1,50 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventLog_WritesJSONLines(t *testing.T) {
+	log, err := OpenEventLog(filepath.Join(t.TempDir(), "events"))
+	require.NoError(t, err)
+	assert.Equal(t, ".jsonl", filepath.Ext(log.Path()))
+
+	log.Write(types.Event{Type: types.EventQueued, URL: "https://example.com/"})
+	log.Write(types.Event{
+		Type:   types.EventCompleted,
+		URL:    "https://example.com/",
+		Scores: &types.CategoryScores{Performance: 91},
+		Result: &types.PageResult{URL: "https://example.com/"},
+	})
+	require.NoError(t, log.Close())
+
+	file, err := os.Open(log.Path())
+	require.NoError(t, err)
+	defer file.Close()
+
+	var lines []map[string]any
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		lines = append(lines, line)
+	}
+	require.Len(t, lines, 2)
+	assert.Equal(t, "queued", lines[0]["type"])
+	assert.Equal(t, "completed", lines[1]["type"])
+	assert.NotContains(t, lines[1], "Result")
+	assert.Equal(t, 91.0, lines[1]["scores"].(map[string]any)["performance"])
+}
+
+func TestOpenEventLog_RejectsExtension(t *testing.T) {
+	_, err := OpenEventLog(filepath.Join(t.TempDir(), "events.exe"))
+	require.ErrorContains(t, err, "failed to create event log")
+}
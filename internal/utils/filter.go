@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/mattjh1/psi-map/internal/types"
+)
+
+// regexRulePrefix marks a filter rule as a regular expression instead of a glob
+const regexRulePrefix = "re:"
+
+// filterRule is a compiled include or exclude rule
+type filterRule struct {
+	Raw       string
+	Pattern   *regexp.Regexp
+	MatchPath bool
+}
+
+// URLFilter decides which URLs are analyzed based on include and exclude rules.
+// A URL is kept when it matches at least one include rule (or none are given)
+// and matches no exclude rule.
+type URLFilter struct {
+	include []*filterRule
+	exclude []*filterRule
+	stats   types.FilterStats
+}
+
+// NewURLFilter compiles include and exclude rules. Rules prefixed with "re:"
+// are regular expressions matched anywhere in the full URL. Any other rule is
+// a glob matched against the whole URL path, or against the whole URL when it
+// starts with a scheme. In globs, * matches within a path segment and **
+// matches across segments.
+func NewURLFilter(include, exclude []string) (*URLFilter, error) {
+	f := &URLFilter{}
+	for _, raw := range include {
+		rule, err := compileFilterRule(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid include rule: %w", err)
+		}
+		f.include = append(f.include, rule)
+	}
+	for _, raw := range exclude {
+		rule, err := compileFilterRule(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude rule: %w", err)
+		}
+		f.exclude = append(f.exclude, rule)
+		f.stats.Rules = append(f.stats.Rules, types.FilterRuleStat{Rule: rule.Raw})
+	}
+	for _, r := range f.include {
+		f.stats.Include = append(f.stats.Include, r.Raw)
+	}
+	return f, nil
+}
+
+func compileFilterRule(raw string) (*filterRule, error) {
+	if expr, ok := strings.CutPrefix(raw, regexRulePrefix); ok {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("%q: %w", raw, err)
+		}
+		return &filterRule{Raw: raw, Pattern: re}, nil
+	}
+
+	if raw == "" {
+		return nil, fmt.Errorf("empty rule")
+	}
+	re, err := regexp.Compile(globToRegexp(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%q: %w", raw, err)
+	}
+	return &filterRule{Raw: raw, Pattern: re, MatchPath: !strings.Contains(raw, "://")}, nil
+}
+
+// globToRegexp converts a glob into an anchored regular expression
+func globToRegexp(glob string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			if i+1 < len(glob) && glob[i+1] == '*' {
+				b.WriteString(".*")
+				i++
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
+}
+
+func (r *filterRule) matches(rawURL string, parsed *url.URL) bool {
+	if r.MatchPath {
+		if parsed == nil {
+			return false
+		}
+		path := parsed.Path
+		if path == "" {
+			path = "/"
+		}
+		return r.Pattern.MatchString(path)
+	}
+	return r.Pattern.MatchString(rawURL)
+}
+
+// IsEmpty reports whether the filter has no rules at all
+func (f *URLFilter) IsEmpty() bool {
+	return f == nil || (len(f.include) == 0 && len(f.exclude) == 0)
+}
+
+// Match reports whether the URL should be kept. When it is dropped, the
+// returned rule names the exclude rule responsible, or is empty when the URL
+// simply matched none of the include rules.
+func (f *URLFilter) Match(rawURL string) (keep bool, rule string) {
+	if f.IsEmpty() {
+		return true, ""
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		parsed = nil
+	}
+
+	if len(f.include) > 0 {
+		included := false
+		for _, r := range f.include {
+			if r.matches(rawURL, parsed) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false, ""
+		}
+	}
+
+	for _, r := range f.exclude {
+		if r.matches(rawURL, parsed) {
+			return false, r.Raw
+		}
+	}
+	return true, ""
+}
+
+// Keep reports whether the URL should be kept and counts it in the filter's
+// running stats
+func (f *URLFilter) Keep(rawURL string) bool {
+	keep, rule := f.Match(rawURL)
+	f.stats.Total++
+	switch {
+	case keep:
+		f.stats.Kept++
+	case rule == "":
+		f.stats.NotIncluded++
+	default:
+		for i := range f.stats.Rules {
+			if f.stats.Rules[i].Rule == rule {
+				f.stats.Rules[i].Excluded++
+				break
+			}
+		}
+	}
+	return keep
+}
+
+// Stats returns the counts of every URL passed to Keep so far
+func (f *URLFilter) Stats() *types.FilterStats {
+	stats := f.stats
+	stats.Excluded = stats.Total - stats.Kept
+	stats.Rules = append([]types.FilterRuleStat(nil), f.stats.Rules...)
+	return &stats
+}
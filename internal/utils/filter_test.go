@@ -0,0 +1,108 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob  string
+		path  string
+		match bool
+	}{
+		{"/tag/*", "/tag/go", true},
+		{"/tag/*", "/tag/go/page/2", false},
+		{"/tag/**", "/tag/go/page/2", true},
+		{"/page/?", "/page/2", true},
+		{"/page/?", "/page/12", false},
+		{"/products/*.html", "/products/shoe.html", true},
+		{"/blog", "/blog/post", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.glob+" "+tt.path, func(t *testing.T) {
+			rule, err := compileFilterRule(tt.glob)
+			require.NoError(t, err)
+			assert.Equal(t, tt.match, rule.Pattern.MatchString(tt.path))
+		})
+	}
+}
+
+func TestURLFilter_Match(t *testing.T) {
+	filter, err := NewURLFilter(
+		[]string{"/blog/**", "https://example.com/about"},
+		[]string{"/blog/tag/**", `re:/page/\d+`},
+	)
+	require.NoError(t, err)
+
+	tests := []struct {
+		url  string
+		keep bool
+		rule string
+	}{
+		{"https://example.com/blog/post", true, ""},
+		{"https://example.com/about", true, ""},
+		{"https://example.com/contact", false, ""},
+		{"https://example.com/blog/tag/go", false, "/blog/tag/**"},
+		{"https://example.com/blog/page/2", false, `re:/page/\d+`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			keep, rule := filter.Match(tt.url)
+			assert.Equal(t, tt.keep, keep)
+			assert.Equal(t, tt.rule, rule)
+		})
+	}
+}
+
+func TestURLFilter_Keep(t *testing.T) {
+	filter, err := NewURLFilter(nil, []string{"/tag/**", `re:/page/\d+$`})
+	require.NoError(t, err)
+
+	urls := []string{
+		"https://example.com/",
+		"https://example.com/tag/go",
+		"https://example.com/tag/rust",
+		"https://example.com/page/2",
+		"https://example.com/post",
+	}
+
+	var kept []string
+	for _, u := range urls {
+		if filter.Keep(u) {
+			kept = append(kept, u)
+		}
+	}
+	stats := filter.Stats()
+	assert.Equal(t, []string{"https://example.com/", "https://example.com/post"}, kept)
+	assert.Equal(t, 5, stats.Total)
+	assert.Equal(t, 2, stats.Kept)
+	assert.Equal(t, 3, stats.Excluded)
+	assert.Equal(t, 0, stats.NotIncluded)
+	require.Len(t, stats.Rules, 2)
+	assert.Equal(t, 2, stats.Rules[0].Excluded)
+	assert.Equal(t, 1, stats.Rules[1].Excluded)
+}
+
+func TestURLFilter_Empty(t *testing.T) {
+	filter, err := NewURLFilter(nil, nil)
+	require.NoError(t, err)
+	assert.True(t, filter.IsEmpty())
+
+	keep, _ := filter.Match("https://example.com/anything")
+	assert.True(t, keep)
+}
+
+func TestNewURLFilter_InvalidRules(t *testing.T) {
+	_, err := NewURLFilter([]string{"re:("}, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid include rule")
+
+	_, err = NewURLFilter(nil, []string{""})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid exclude rule")
+}
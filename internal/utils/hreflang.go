@@ -0,0 +1,181 @@
+package utils
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/mattjh1/psi-map/internal/constants"
+	"github.com/mattjh1/psi-map/internal/logger"
+	"github.com/mattjh1/psi-map/internal/server"
+	"github.com/mattjh1/psi-map/internal/types"
+)
+
+// AlternateExpander adds the hreflang alternates of each entry that have not
+// been seen yet, so alternates that are not listed as sitemap entries
+// themselves get analyzed too
+type AlternateExpander struct {
+	seen map[string]bool
+}
+
+// NewAlternateExpander returns an expander that has seen no URLs yet
+func NewAlternateExpander() *AlternateExpander {
+	return &AlternateExpander{seen: make(map[string]bool)}
+}
+
+// Expand returns the entry, unless it was already added as an alternate,
+// followed by its unseen alternates
+func (e *AlternateExpander) Expand(entry types.URL) []types.URL {
+	expanded := make([]types.URL, 0, 1+len(entry.Alternates))
+	if !e.seen[entry.Loc] {
+		e.seen[entry.Loc] = true
+		expanded = append(expanded, entry)
+	}
+	for _, alt := range entry.Alternates {
+		if e.seen[alt.Href] {
+			continue
+		}
+		e.seen[alt.Href] = true
+		expanded = append(expanded, types.URL{Loc: alt.Href, Alternates: entry.Alternates})
+	}
+	return expanded
+}
+
+// BuildLocaleGroups links entries through their hreflang alternates. Each
+// group's canonical page is its x-default alternate, or else the first URL
+// listed in the sitemap. Pages without alternates are left out.
+func BuildLocaleGroups(entries []types.URL) []types.LocaleGroup {
+	parent := make(map[string]string)
+	var find func(string) string
+	find = func(u string) string {
+		if p, ok := parent[u]; ok && p != u {
+			root := find(p)
+			parent[u] = root
+			return root
+		}
+		parent[u] = u
+		return u
+	}
+	union := func(a, b string) {
+		if ra, rb := find(a), find(b); ra != rb {
+			parent[rb] = ra
+		}
+	}
+
+	order := make([]string, 0)
+	seen := make(map[string]bool)
+	note := func(u string) {
+		if !seen[u] {
+			seen[u] = true
+			order = append(order, u)
+		}
+	}
+	locales := make(map[string]string)
+	xDefault := make(map[string]bool)
+
+	for _, entry := range entries {
+		if len(entry.Alternates) == 0 {
+			continue
+		}
+		note(entry.Loc)
+		for _, alt := range entry.Alternates {
+			note(alt.Href)
+			union(entry.Loc, alt.Href)
+			if strings.EqualFold(alt.Hreflang, constants.HreflangXDefault) {
+				xDefault[alt.Href] = true
+			} else if _, ok := locales[alt.Href]; !ok {
+				locales[alt.Href] = alt.Hreflang
+			}
+		}
+	}
+
+	members := make(map[string][]string)
+	roots := make([]string, 0)
+	for _, u := range order {
+		root := find(u)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], u)
+	}
+
+	groups := make([]types.LocaleGroup, 0, len(roots))
+	for _, root := range roots {
+		urls := members[root]
+		if len(urls) < 2 {
+			continue
+		}
+
+		group := types.LocaleGroup{Canonical: urls[0]}
+		for _, u := range urls {
+			if xDefault[u] {
+				group.Canonical = u
+				break
+			}
+		}
+		for _, u := range urls {
+			locale, ok := locales[u]
+			if !ok {
+				locale = constants.HreflangXDefault
+			}
+			group.Variants = append(group.Variants, types.LocaleVariant{Locale: locale, URL: u})
+		}
+		sort.SliceStable(group.Variants, func(i, j int) bool {
+			return group.Variants[i].Locale < group.Variants[j].Locale
+		})
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// CompareLocales fills in each variant's scores from the results and records
+// the performance gap between the best and worst analyzed locale
+func CompareLocales(groups []types.LocaleGroup, results []*types.PageResult) {
+	byURL := resultsByURL(results)
+
+	for i := range groups {
+		group := &groups[i]
+		best, worst := -1.0, -1.0
+		for j := range group.Variants {
+			variant := &group.Variants[j]
+			result, ok := byURL[variant.URL]
+			if !ok {
+				continue
+			}
+			summary := server.GenerateSummary([]*types.PageResult{result})
+			if summary.SuccessfulPages == 0 {
+				continue
+			}
+			variant.Analyzed = true
+			variant.Scores = summary.AverageScores
+
+			perf, ok := variant.Scores["performance"]
+			if !ok {
+				continue
+			}
+			if best < 0 || perf > best {
+				best = perf
+			}
+			if worst < 0 || perf < worst {
+				worst = perf
+				group.SlowestLocale = variant.Locale
+			}
+		}
+		if best >= 0 {
+			group.PerformanceGap = best - worst
+		}
+	}
+}
+
+// PrintLocaleReport logs pages whose locales differ noticeably in performance
+func PrintLocaleReport(groups []types.LocaleGroup) {
+	log := logger.GetLogger()
+	log.Tagged("HREFLANG", "Compared %d page(s) across their locales", "🌐", len(groups))
+	for i := range groups {
+		group := &groups[i]
+		if group.PerformanceGap < constants.LocaleGapWarnThreshold {
+			continue
+		}
+		log.Tagged("HREFLANG", "  %s: %s is %.0f performance points behind the fastest locale", "",
+			group.Canonical, group.SlowestLocale, group.PerformanceGap)
+	}
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func localeAlternates() []types.Alternate {
+	return []types.Alternate{
+		{Rel: "alternate", Hreflang: "en", Href: "https://example.com/en/"},
+		{Rel: "alternate", Hreflang: "de", Href: "https://example.com/de/"},
+		{Rel: "alternate", Hreflang: "x-default", Href: "https://example.com/en/"},
+	}
+}
+
+func TestAlternateExpander(t *testing.T) {
+	entries := []types.URL{
+		{Loc: "https://example.com/en/", Alternates: localeAlternates()},
+		{Loc: "https://example.com/about"},
+		{Loc: "https://example.com/de/", Alternates: localeAlternates()},
+	}
+
+	expander := NewAlternateExpander()
+	var expanded []types.URL
+	for _, entry := range entries {
+		expanded = append(expanded, expander.Expand(entry)...)
+	}
+	require.Len(t, expanded, 3, "entries already added as alternates should not repeat")
+	assert.Equal(t, "https://example.com/en/", expanded[0].Loc)
+	assert.Equal(t, "https://example.com/de/", expanded[1].Loc, "alternates should follow the entry listing them")
+	assert.Equal(t, "https://example.com/about", expanded[2].Loc)
+}
+
+func TestBuildLocaleGroups(t *testing.T) {
+	entries := []types.URL{
+		{Loc: "https://example.com/about"},
+		{Loc: "https://example.com/de/", Alternates: localeAlternates()},
+		{Loc: "https://example.com/en/", Alternates: localeAlternates()},
+		{Loc: "https://example.com/fr/", Alternates: []types.Alternate{
+			{Rel: "alternate", Hreflang: "fr", Href: "https://example.com/fr/"},
+			{Rel: "alternate", Hreflang: "en", Href: "https://example.com/en/"},
+		}},
+	}
+
+	groups := BuildLocaleGroups(entries)
+	require.Len(t, groups, 1)
+	assert.Equal(t, "https://example.com/en/", groups[0].Canonical, "x-default should be the canonical page")
+	assert.Equal(t, []types.LocaleVariant{
+		{Locale: "de", URL: "https://example.com/de/"},
+		{Locale: "en", URL: "https://example.com/en/"},
+		{Locale: "fr", URL: "https://example.com/fr/"},
+	}, groups[0].Variants)
+}
+
+func TestCompareLocales(t *testing.T) {
+	groups := []types.LocaleGroup{{
+		Canonical: "https://example.com/en/",
+		Variants: []types.LocaleVariant{
+			{Locale: "de", URL: "https://example.com/de/"},
+			{Locale: "en", URL: "https://example.com/en/"},
+			{Locale: "fr", URL: "https://example.com/fr/"},
+		},
+	}}
+	results := []*types.PageResult{
+		{URL: "https://example.com/de/", Mobile: &types.Result{Scores: &types.CategoryScores{Performance: 55}}},
+		{URL: "https://example.com/en/", Mobile: &types.Result{Scores: &types.CategoryScores{Performance: 92}}},
+	}
+
+	CompareLocales(groups, results)
+
+	group := groups[0]
+	assert.True(t, group.Variants[0].Analyzed)
+	assert.InDelta(t, 55.0, group.Variants[0].Scores["performance"], 0.001)
+	assert.False(t, group.Variants[2].Analyzed)
+	assert.InDelta(t, 37.0, group.PerformanceGap, 0.001)
+	assert.Equal(t, "de", group.SlowestLocale)
+}
+
+func TestURLDeduper_Alternates(t *testing.T) {
+	entries := []types.URL{{
+		Loc:        "https://Example.com/en/",
+		Alternates: []types.Alternate{{Rel: "alternate", Hreflang: "de", Href: "https://EXAMPLE.com/de/"}},
+	}}
+
+	deduper, err := NewURLDeduper(&types.NormalizeConfig{LowercaseHost: true})
+	require.NoError(t, err)
+	entry, ok := deduper.Add(entries[0])
+	require.True(t, ok)
+	assert.Equal(t, "https://example.com/de/", entry.Alternates[0].Href)
+	assert.Equal(t, "https://EXAMPLE.com/de/", entries[0].Alternates[0].Href, "input alternates should not be modified")
+}
@@ -0,0 +1,437 @@
+package utils
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattjh1/psi-map/internal/constants"
+	"github.com/mattjh1/psi-map/internal/types"
+)
+
+// sitemapNamespace is the namespace required on <urlset> and <sitemapindex>
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+var validChangefreq = map[string]bool{
+	"always": true, "hourly": true, "daily": true, "weekly": true,
+	"monthly": true, "yearly": true, "never": true,
+}
+
+// statusClient does not follow redirects so they can be reported
+var statusClient = &http.Client{
+	Timeout: constants.StatusCheckTimeout,
+	CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+// inspectEntry keeps every child element so repeated or missing ones can be reported
+type inspectEntry struct {
+	Locs        []string `xml:"loc"`
+	Lastmods    []string `xml:"lastmod"`
+	Priorities  []string `xml:"priority"`
+	Changefreqs []string `xml:"changefreq"`
+}
+
+// lastmodBuckets are the age buckets of the lastmod distribution, in order
+var lastmodBuckets = []struct {
+	Label  string
+	MaxAge time.Duration
+}{
+	{"last 7 days", 7 * 24 * time.Hour},
+	{"last 30 days", 30 * 24 * time.Hour},
+	{"last 90 days", 90 * 24 * time.Hour},
+	{"last year", 365 * 24 * time.Hour},
+}
+
+const (
+	lastmodOlderLabel  = "older"
+	lastmodFutureLabel = "in the future"
+)
+
+// sitemapInspector accumulates findings while walking a sitemap and its children
+type sitemapInspector struct {
+	report  *types.SitemapInspection
+	now     time.Time
+	counts  map[string]int
+	unique  []string
+	buckets map[string]int
+	oldest  time.Time
+	newest  time.Time
+}
+
+// InspectSitemap checks a sitemap, and the child sitemaps of an index, for
+// schema problems, invalid locs, duplicates, off-domain URLs and stale
+// lastmod values without calling PSI
+func InspectSitemap(input string, opts *types.InspectOptions) (*types.SitemapInspection, error) {
+	in := &sitemapInspector{
+		report: &types.SitemapInspection{
+			Input:      input,
+			Issues:     []types.SitemapIssue{},
+			Duplicates: []types.DuplicateURL{},
+			OffDomain:  []string{},
+		},
+		now:     time.Now(),
+		counts:  make(map[string]int),
+		buckets: make(map[string]int),
+	}
+	if isRemoteInput(input) {
+		if u, err := url.Parse(input); err == nil {
+			in.report.Domain = siteHost(u.Host)
+		}
+	}
+
+	if err := in.inspect(input, 0); err != nil {
+		return nil, err
+	}
+	in.finish()
+
+	if opts != nil && opts.CheckStatus {
+		in.report.Status = CheckURLStatuses(in.unique, opts.Concurrency)
+	}
+	return in.report, nil
+}
+
+// inspect walks one sitemap document. Only failing to open the top-level
+// input is an error; everything else is recorded as an issue.
+func (in *sitemapInspector) inspect(doc string, depth int) error {
+	reader, err := openSitemap(context.Background(), doc)
+	if err != nil {
+		if depth == 0 {
+			return err
+		}
+		in.issue(doc, 0, "", fmt.Sprintf("failed to open child sitemap: %v", err))
+		return nil
+	}
+	defer reader.Close()
+	in.report.Sitemaps = append(in.report.Sitemaps, doc)
+
+	decoder := xml.NewDecoder(reader)
+	root := ""
+	urls := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		line, _ := decoder.InputPos()
+		if err != nil {
+			in.issue(doc, line, "", fmt.Sprintf("XML syntax error: %v", err))
+			return nil
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		if root == "" {
+			root = start.Name.Local
+			switch root {
+			case "urlset", "sitemapindex":
+				if start.Name.Space != sitemapNamespace {
+					in.issue(doc, line, "", fmt.Sprintf("<%s> should declare xmlns=%q", root, sitemapNamespace))
+				}
+			default:
+				in.issue(doc, line, "", fmt.Sprintf("unexpected root element <%s>, expected <urlset> or <sitemapindex>", root))
+				return nil
+			}
+			continue
+		}
+
+		switch start.Name.Local {
+		case "url":
+			if root != "urlset" {
+				in.issue(doc, line, "", "<url> is only allowed in <urlset>")
+			}
+			var entry inspectEntry
+			if err := decoder.DecodeElement(&entry, &start); err != nil {
+				in.issue(doc, line, "", fmt.Sprintf("XML syntax error: %v", err))
+				return nil
+			}
+			urls++
+			if urls == constants.MaxURLsPerSitemap+1 {
+				in.issue(doc, line, "", fmt.Sprintf("more than %d URLs in one sitemap", constants.MaxURLsPerSitemap))
+			}
+			in.checkEntry(doc, line, &entry)
+		case "sitemap":
+			if root != "sitemapindex" {
+				in.issue(doc, line, "", "<sitemap> is only allowed in <sitemapindex>")
+			}
+			var entry inspectEntry
+			if err := decoder.DecodeElement(&entry, &start); err != nil {
+				in.issue(doc, line, "", fmt.Sprintf("XML syntax error: %v", err))
+				return nil
+			}
+			loc, ok := in.checkLoc(doc, line, entry.Locs)
+			if !ok {
+				continue
+			}
+			if depth >= constants.MaxSitemapIndexDepth {
+				in.issue(doc, line, loc, fmt.Sprintf("sitemap index nested deeper than %d levels", constants.MaxSitemapIndexDepth))
+				continue
+			}
+			if err := in.inspect(loc, depth+1); err != nil {
+				return err
+			}
+		default:
+			in.issue(doc, line, "", fmt.Sprintf("unexpected element <%s> in <%s>", start.Name.Local, root))
+			if err := decoder.Skip(); err != nil {
+				in.issue(doc, line, "", fmt.Sprintf("XML syntax error: %v", err))
+				return nil
+			}
+		}
+	}
+
+	if root == "" {
+		in.issue(doc, 0, "", "document has no root element")
+	}
+	return nil
+}
+
+// checkLoc validates the <loc> elements of an entry and returns the usable URL
+func (in *sitemapInspector) checkLoc(doc string, line int, locs []string) (string, bool) {
+	switch len(locs) {
+	case 0:
+		in.issue(doc, line, "", "missing <loc>")
+		return "", false
+	case 1:
+	default:
+		in.issue(doc, line, strings.TrimSpace(locs[0]), fmt.Sprintf("%d <loc> elements, expected one", len(locs)))
+	}
+
+	loc := strings.TrimSpace(locs[0])
+	u, err := url.Parse(loc)
+	switch {
+	case loc == "":
+		in.issue(doc, line, "", "empty <loc>")
+		return "", false
+	case err != nil:
+		in.issue(doc, line, loc, fmt.Sprintf("invalid URL: %v", err))
+		return "", false
+	case !u.IsAbs() || u.Host == "":
+		in.issue(doc, line, loc, "relative URL, <loc> must be absolute")
+		return "", false
+	case u.Scheme != "http" && u.Scheme != "https":
+		in.issue(doc, line, loc, fmt.Sprintf("unsupported URL scheme: %s", u.Scheme))
+		return "", false
+	}
+	if len(loc) > constants.MaxLocLength {
+		in.issue(doc, line, loc, fmt.Sprintf("URL longer than %d characters", constants.MaxLocLength))
+	}
+	return loc, true
+}
+
+// checkEntry validates a <url> entry and records it in the stats
+func (in *sitemapInspector) checkEntry(doc string, line int, entry *inspectEntry) {
+	in.report.TotalURLs++
+	loc, ok := in.checkLoc(doc, line, entry.Locs)
+	if !ok {
+		return
+	}
+	in.report.ValidURLs++
+	if in.counts[loc] == 0 {
+		in.unique = append(in.unique, loc)
+	}
+	in.counts[loc]++
+
+	if len(entry.Lastmods) == 0 {
+		in.report.Lastmod.Missing++
+	} else {
+		raw := strings.TrimSpace(entry.Lastmods[0])
+		if t := entryLastmod(types.URL{Lastmod: raw}); t.IsZero() {
+			in.report.Lastmod.Invalid++
+			in.issue(doc, line, loc, fmt.Sprintf("invalid <lastmod> %q, expected a W3C datetime", raw))
+		} else {
+			in.recordLastmod(t)
+		}
+	}
+
+	if len(entry.Priorities) > 0 {
+		raw := strings.TrimSpace(entry.Priorities[0])
+		if p, err := strconv.ParseFloat(raw, 64); err != nil || p < 0 || p > 1 {
+			in.issue(doc, line, loc, fmt.Sprintf("invalid <priority> %q, expected 0.0 to 1.0", raw))
+		}
+	}
+	if len(entry.Changefreqs) > 0 {
+		raw := strings.TrimSpace(entry.Changefreqs[0])
+		if !validChangefreq[raw] {
+			in.issue(doc, line, loc, fmt.Sprintf("invalid <changefreq> %q", raw))
+		}
+	}
+}
+
+func (in *sitemapInspector) recordLastmod(t time.Time) {
+	if in.oldest.IsZero() || t.Before(in.oldest) {
+		in.oldest = t
+	}
+	if t.After(in.newest) {
+		in.newest = t
+	}
+
+	age := in.now.Sub(t)
+	if age < -24*time.Hour {
+		in.buckets[lastmodFutureLabel]++
+		return
+	}
+	for _, bucket := range lastmodBuckets {
+		if age <= bucket.MaxAge {
+			in.buckets[bucket.Label]++
+			return
+		}
+	}
+	in.buckets[lastmodOlderLabel]++
+}
+
+func (in *sitemapInspector) issue(doc string, line int, loc, message string) {
+	in.report.Issues = append(in.report.Issues, types.SitemapIssue{Sitemap: doc, Line: line, URL: loc, Message: message})
+}
+
+// finish derives the duplicate, domain, section and lastmod summaries
+func (in *sitemapInspector) finish() {
+	report := in.report
+	hosts := make(map[string]int)
+	sections := make(map[string]int)
+	for _, loc := range in.unique {
+		if n := in.counts[loc]; n > 1 {
+			report.Duplicates = append(report.Duplicates, types.DuplicateURL{URL: loc, Count: n})
+		}
+		u, err := url.Parse(loc)
+		if err != nil {
+			continue
+		}
+		hosts[siteHost(u.Host)]++
+		sections[pathSection(u.Path)]++
+	}
+
+	// Local files have no domain of their own, so use the most common host
+	if report.Domain == "" {
+		best := 0
+		for host, n := range hosts {
+			if n > best || (n == best && host < report.Domain) {
+				report.Domain, best = host, n
+			}
+		}
+	}
+	for _, loc := range in.unique {
+		if u, err := url.Parse(loc); err == nil && siteHost(u.Host) != report.Domain {
+			report.OffDomain = append(report.OffDomain, loc)
+		}
+	}
+
+	for section, n := range sections {
+		report.Sections = append(report.Sections, types.SectionCount{Section: section, Count: n})
+	}
+	sort.Slice(report.Sections, func(i, j int) bool {
+		a, b := report.Sections[i], report.Sections[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Section < b.Section
+	})
+
+	labels := []string{lastmodFutureLabel}
+	for _, bucket := range lastmodBuckets {
+		labels = append(labels, bucket.Label)
+	}
+	labels = append(labels, lastmodOlderLabel)
+	for _, label := range labels {
+		if n := in.buckets[label]; n > 0 {
+			report.Lastmod.Buckets = append(report.Lastmod.Buckets, types.LastmodCount{Label: label, Count: n})
+		}
+	}
+	if !in.oldest.IsZero() {
+		report.Lastmod.Oldest = in.oldest.Format(time.DateOnly)
+		report.Lastmod.Newest = in.newest.Format(time.DateOnly)
+	}
+}
+
+// siteHost lowercases the host and drops a leading www. so both count as one site
+func siteHost(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
+
+// pathSection returns the first path segment, e.g. /blog for /blog/post-1
+func pathSection(path string) string {
+	seg, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	return "/" + seg
+}
+
+// CheckURLStatuses probes every URL without following redirects and reports
+// the ones that did not answer 200 OK, in input order
+func CheckURLStatuses(urls []string, concurrency int) *types.StatusSummary {
+	concurrency = max(1, concurrency)
+	statuses := make([]types.URLStatus, len(urls))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, concurrency)
+
+	for i, u := range urls {
+		wg.Add(1)
+		go func(i int, u string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			statuses[i] = probeURL(u)
+		}(i, u)
+	}
+	wg.Wait()
+
+	summary := &types.StatusSummary{Checked: len(urls), Problems: []types.URLStatus{}}
+	for _, status := range statuses {
+		switch {
+		case status.Error == "" && status.StatusCode == http.StatusOK:
+			summary.OK++
+			continue
+		case status.Error == "" && status.StatusCode >= 300 && status.StatusCode < 400:
+			summary.Redirects++
+		default:
+			summary.Errors++
+		}
+		summary.Problems = append(summary.Problems, status)
+	}
+	return summary
+}
+
+// probeURL sends a HEAD request, falling back to GET for servers that reject HEAD
+func probeURL(rawURL string) types.URLStatus {
+	status := types.URLStatus{URL: rawURL}
+	resp, err := statusRequest(http.MethodHead, rawURL)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		resp, err = statusRequest(http.MethodGet, rawURL)
+	}
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	defer resp.Body.Close()
+
+	status.StatusCode = resp.StatusCode
+	status.Location = resp.Header.Get("Location")
+	return status
+}
+
+func statusRequest(method, rawURL string) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), constants.StatusCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", constants.CrawlUserAgent)
+
+	resp, err := statusClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func issueMessages(issues []types.SitemapIssue) []string {
+	messages := make([]string, 0, len(issues))
+	for _, issue := range issues {
+		messages = append(messages, issue.Message)
+	}
+	return messages
+}
+
+func TestInspectSitemap(t *testing.T) {
+	path := writeTempSitemap(t, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc>https://example.com/</loc><lastmod>2000-01-01</lastmod></url>
+  <url><loc>https://www.example.com/blog/a</loc><priority>1.5</priority></url>
+  <url><loc>https://example.com/blog/b</loc><lastmod>3000-01-01T00:00:00Z</lastmod></url>
+  <url><loc>https://www.example.com/blog/a</loc></url>
+  <url><loc>/relative</loc></url>
+  <url><lastmod>2000-01-01</lastmod></url>
+  <url><loc>https://cdn.other.org/file</loc><lastmod>soon</lastmod><changefreq>sometimes</changefreq></url>
+</urlset>`)
+
+	inspection, err := InspectSitemap(path, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "example.com", inspection.Domain, "local sitemaps use their most common host")
+	assert.Equal(t, 7, inspection.TotalURLs)
+	assert.Equal(t, 5, inspection.ValidURLs)
+	assert.Equal(t, []types.DuplicateURL{{URL: "https://www.example.com/blog/a", Count: 2}}, inspection.Duplicates)
+	assert.Equal(t, []string{"https://cdn.other.org/file"}, inspection.OffDomain)
+	assert.Equal(t, []types.SectionCount{{Section: "/blog", Count: 2}, {Section: "/", Count: 1}, {Section: "/file", Count: 1}}, inspection.Sections)
+
+	assert.Equal(t, []string{
+		`invalid <priority> "1.5", expected 0.0 to 1.0`,
+		"relative URL, <loc> must be absolute",
+		"missing <loc>",
+		`invalid <lastmod> "soon", expected a W3C datetime`,
+		`invalid <changefreq> "sometimes"`,
+	}, issueMessages(inspection.Issues))
+	assert.Equal(t, 4, inspection.Issues[0].Line)
+
+	assert.Equal(t, 2, inspection.Lastmod.Missing)
+	assert.Equal(t, 1, inspection.Lastmod.Invalid)
+	assert.Equal(t, "2000-01-01", inspection.Lastmod.Oldest)
+	assert.Equal(t, "3000-01-01", inspection.Lastmod.Newest)
+	assert.Equal(t, []types.LastmodCount{{Label: "in the future", Count: 1}, {Label: "older", Count: 1}}, inspection.Lastmod.Buckets)
+	assert.Nil(t, inspection.Status)
+}
+
+func TestInspectSitemap_SchemaProblems(t *testing.T) {
+	inspection, err := InspectSitemap(writeTempSitemap(t, `<urlset>
+  <url><loc>https://example.com/</loc><loc>https://example.com/again</loc></url>
+  <extra/>
+</urlset>`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		`<urlset> should declare xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"`,
+		"2 <loc> elements, expected one",
+		"unexpected element <extra> in <urlset>",
+	}, issueMessages(inspection.Issues))
+
+	inspection, err = InspectSitemap(writeTempSitemap(t, `<rss><channel/></rss>`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"unexpected root element <rss>, expected <urlset> or <sitemapindex>"}, issueMessages(inspection.Issues))
+
+	inspection, err = InspectSitemap(writeTempSitemap(t, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"><url><loc>https://example.com/`), nil)
+	require.NoError(t, err)
+	require.Len(t, inspection.Issues, 1)
+	assert.Contains(t, inspection.Issues[0].Message, "XML syntax error")
+
+	_, err = InspectSitemap("does-not-exist.xml", nil)
+	assert.Error(t, err)
+}
+
+func TestInspectSitemap_IndexAndStatus(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+			<sitemap><loc>` + server.URL + `/pages.xml</loc></sitemap>
+			<sitemap><loc>` + server.URL + `/missing.xml</loc></sitemap>
+		</sitemapindex>`))
+	})
+	mux.HandleFunc("/pages.xml", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+			<url><loc>` + server.URL + `/ok</loc></url>
+			<url><loc>` + server.URL + `/moved</loc></url>
+			<url><loc>` + server.URL + `/gone</loc></url>
+			<url><loc>` + server.URL + `/no-head</loc></url>
+		</urlset>`))
+	})
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/missing.xml", http.NotFound)
+	mux.HandleFunc("/gone", http.NotFound)
+
+	inspection, err := InspectSitemap(server.URL+"/sitemap.xml", &types.InspectOptions{CheckStatus: true, Concurrency: 2})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{server.URL + "/sitemap.xml", server.URL + "/pages.xml"}, inspection.Sitemaps)
+	assert.Equal(t, 4, inspection.ValidURLs)
+	assert.Empty(t, inspection.OffDomain)
+	require.Len(t, inspection.Issues, 1)
+	assert.Contains(t, inspection.Issues[0].Message, "failed to open child sitemap")
+
+	status := inspection.Status
+	require.NotNil(t, status)
+	assert.Equal(t, 4, status.Checked)
+	assert.Equal(t, 2, status.OK, "GET should be used when HEAD is not allowed")
+	assert.Equal(t, 1, status.Redirects)
+	assert.Equal(t, 1, status.Errors)
+	assert.Equal(t, []types.URLStatus{
+		{URL: server.URL + "/moved", StatusCode: http.StatusMovedPermanently, Location: "/ok"},
+		{URL: server.URL + "/gone", StatusCode: http.StatusNotFound},
+	}, status.Problems)
+}
+
+func TestInspectSitemap_RemoteIndexCannotOpenLocalFiles(t *testing.T) {
+	local := writeTempSitemap(t, `<urlset><url><loc>https://example.com/secret</loc></url></urlset>`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+			<sitemap><loc>` + local + `</loc></sitemap>
+		</sitemapindex>`))
+	}))
+	defer server.Close()
+
+	inspection, err := InspectSitemap(server.URL, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{server.URL}, inspection.Sitemaps)
+	assert.Equal(t, []string{"relative URL, <loc> must be absolute"}, issueMessages(inspection.Issues))
+}
@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/mattjh1/psi-map/internal/logger"
+	"github.com/mattjh1/psi-map/internal/server"
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/mattjh1/psi-map/internal/utils/validate"
+)
+
+// LoadReport reads a JSON report written by analyze
+func LoadReport(path string) (*types.ReportData, error) {
+	validatedPath, err := validate.ValidateInputPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid report path: %w", err)
+	}
+	data, err := os.ReadFile(validatedPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report: %w", err)
+	}
+	var report types.ReportData
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s (only JSON reports can be merged): %w", path, err)
+	}
+	return &report, nil
+}
+
+// MergeReports combines the reports of several shards of one run into a
+// single report with one summary. Pages are matched by site and URL and the
+// first copy wins. Locale and sample stats are recomputed over all pages.
+// When shards are missing, the merged report is marked incomplete.
+func MergeReports(reports []*types.ReportData) (*types.ReportData, error) {
+	if len(reports) == 0 {
+		return nil, fmt.Errorf("no reports to merge")
+	}
+	missing, shards, err := missingShards(reports)
+	if err != nil {
+		return nil, err
+	}
+
+	type pageKey struct{ site, url string }
+	seen := make(map[pageKey]bool)
+	var results []*types.PageResult
+	duplicates := 0
+	incomplete := len(missing) > 0
+	for _, report := range reports {
+		incomplete = incomplete || report.Incomplete
+		for _, result := range report.Results {
+			if result == nil {
+				continue
+			}
+			key := pageKey{result.Site, result.URL}
+			if seen[key] {
+				duplicates++
+				continue
+			}
+			seen[key] = true
+			results = append(results, result)
+		}
+	}
+
+	merged := server.NewReport(results)
+	merged.Incomplete = incomplete
+	first := reports[0]
+	merged.Dedupe = first.Dedupe
+	merged.Filter = first.Filter
+	merged.Locales = first.Locales
+	merged.Sample = first.Sample
+	for _, report := range reports {
+		merged.Skipped = append(merged.Skipped, report.Skipped...)
+		merged.Trickle = addTrickle(merged.Trickle, report.Trickle)
+	}
+	refreshDerivedStats(merged.Locales, merged.Sample, results)
+	merged.Sites = mergeSiteReports(reports, results)
+
+	log := logger.GetLogger()
+	if duplicates > 0 {
+		log.Warn("Dropped %d page(s) found in more than one report", duplicates)
+	}
+	if len(missing) > 0 {
+		log.Warn("Shard(s) %v of %d are missing, the merged report is incomplete", missing, shards)
+	}
+	return merged, nil
+}
+
+// missingShards checks that sharded reports belong to one split and returns
+// the shard numbers not given, along with the shard count. Reports without
+// shard info are merged as is.
+func missingShards(reports []*types.ReportData) (missing []int, count int, err error) {
+	given := make(map[int]bool)
+	for _, report := range reports {
+		if report.Shard == nil {
+			continue
+		}
+		if count != 0 && report.Shard.Count != count {
+			return nil, 0, fmt.Errorf("reports come from different splits (%d and %d shards)", count, report.Shard.Count)
+		}
+		count = report.Shard.Count
+		if given[report.Shard.Index] {
+			return nil, 0, fmt.Errorf("shard %d/%d is given twice", report.Shard.Index, count)
+		}
+		given[report.Shard.Index] = true
+	}
+	for i := 1; i <= count; i++ {
+		if !given[i] {
+			missing = append(missing, i)
+		}
+	}
+	return missing, count, nil
+}
+
+// mergeSiteReports combines the per-site sections of multi-site reports
+func mergeSiteReports(reports []*types.ReportData, results []*types.PageResult) []types.SiteReport {
+	var sites []types.SiteReport
+	index := make(map[string]int)
+	for _, report := range reports {
+		for _, site := range report.Sites {
+			i, ok := index[site.Name]
+			if !ok {
+				index[site.Name] = len(sites)
+				site.Skipped = append([]types.SkippedURL(nil), site.Skipped...)
+				sites = append(sites, site)
+				continue
+			}
+			sites[i].Skipped = append(sites[i].Skipped, site.Skipped...)
+			sites[i].Trickle = addTrickle(sites[i].Trickle, site.Trickle)
+			if sites[i].Error == "" {
+				sites[i].Error = site.Error
+			}
+		}
+	}
+
+	bySite := make(map[string][]*types.PageResult, len(sites))
+	for _, result := range results {
+		bySite[result.Site] = append(bySite[result.Site], result)
+	}
+	for i := range sites {
+		siteResults := bySite[sites[i].Name]
+		sites[i].Summary = server.GenerateSummary(siteResults)
+		refreshDerivedStats(sites[i].Locales, sites[i].Sample, siteResults)
+	}
+	return sites
+}
+
+// addTrickle sums the trickle stats of two shards without changing either
+func addTrickle(a, b *types.TrickleReport) *types.TrickleReport {
+	if a == nil || b == nil {
+		if a == nil {
+			return b
+		}
+		return a
+	}
+	return &types.TrickleReport{
+		Budget:   a.Budget + b.Budget,
+		Order:    a.Order,
+		Total:    a.Total + b.Total,
+		New:      a.New + b.New,
+		Expired:  a.Expired + b.Expired,
+		Analyzed: a.Analyzed + b.Analyzed,
+		Pending:  a.Pending + b.Pending,
+	}
+}
+
+// refreshDerivedStats recomputes locale and sample stats over all pages
+func refreshDerivedStats(locales []types.LocaleGroup, sample *types.SampleReport, results []*types.PageResult) {
+	if len(locales) > 0 {
+		CompareLocales(locales, results)
+	}
+	if sample != nil {
+		ExtrapolateSample(sample, results)
+	}
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mergeTestPage(url string, perf float64) *types.PageResult {
+	scores := &types.CategoryScores{Performance: perf, Accessibility: 90, BestPractices: 90, SEO: 90}
+	return &types.PageResult{
+		URL:     url,
+		Mobile:  &types.Result{URL: url, Strategy: "mobile", Scores: scores},
+		Desktop: &types.Result{URL: url, Strategy: "desktop", Scores: scores},
+	}
+}
+
+func TestMergeReports_CombinesShards(t *testing.T) {
+	a := &types.ReportData{
+		Shard:   &types.ShardConfig{Index: 1, Count: 2},
+		Results: []*types.PageResult{mergeTestPage("https://example.com/a", 80)},
+		Skipped: []types.SkippedURL{{URL: "https://example.com/x"}},
+	}
+	b := &types.ReportData{
+		Shard:   &types.ShardConfig{Index: 2, Count: 2},
+		Results: []*types.PageResult{mergeTestPage("https://example.com/b", 60), mergeTestPage("https://example.com/a", 10)},
+	}
+
+	merged, err := MergeReports([]*types.ReportData{a, b})
+	require.NoError(t, err)
+	assert.False(t, merged.Incomplete)
+	assert.Nil(t, merged.Shard)
+	require.Len(t, merged.Results, 2)
+	assert.Equal(t, 80.0, merged.Results[0].Mobile.Scores.Performance)
+	assert.Equal(t, 2, merged.Summary.TotalPages)
+	assert.InDelta(t, 70.0, merged.Summary.AverageScores["performance"], 0.01)
+	assert.Len(t, merged.Skipped, 1)
+}
+
+func TestMergeReports_MissingShardIsIncomplete(t *testing.T) {
+	a := &types.ReportData{Shard: &types.ShardConfig{Index: 1, Count: 3}}
+	c := &types.ReportData{Shard: &types.ShardConfig{Index: 3, Count: 3}}
+	merged, err := MergeReports([]*types.ReportData{a, c})
+	require.NoError(t, err)
+	assert.True(t, merged.Incomplete)
+}
+
+func TestMergeReports_RejectsMismatchedShards(t *testing.T) {
+	a := &types.ReportData{Shard: &types.ShardConfig{Index: 1, Count: 2}}
+	b := &types.ReportData{Shard: &types.ShardConfig{Index: 1, Count: 4}}
+	_, err := MergeReports([]*types.ReportData{a, b})
+	require.ErrorContains(t, err, "different splits")
+
+	_, err = MergeReports([]*types.ReportData{a, a})
+	require.ErrorContains(t, err, "given twice")
+}
+
+func TestMergeReports_SumsTrickleStats(t *testing.T) {
+	a := &types.ReportData{
+		Shard:   &types.ShardConfig{Index: 1, Count: 2},
+		Trickle: &types.TrickleReport{Budget: 10, Order: types.TrickleOldest, Total: 40, New: 12, Analyzed: 10, Pending: 2},
+	}
+	b := &types.ReportData{
+		Shard:   &types.ShardConfig{Index: 2, Count: 2},
+		Trickle: &types.TrickleReport{Budget: 10, Order: types.TrickleOldest, Total: 38, Expired: 5, Analyzed: 5},
+	}
+
+	merged, err := MergeReports([]*types.ReportData{a, b})
+	require.NoError(t, err)
+	require.NotNil(t, merged.Trickle)
+	assert.Equal(t, 78, merged.Trickle.Total)
+	assert.Equal(t, 15, merged.Trickle.Analyzed)
+	assert.Equal(t, 2, merged.Trickle.Pending)
+	assert.Equal(t, 10, a.Trickle.Analyzed, "shard reports are left as they were")
+}
+
+func TestMergeReports_RecomputesSites(t *testing.T) {
+	pageA := mergeTestPage("https://a.example/", 90)
+	pageA.Site = "a.example"
+	pageB := mergeTestPage("https://b.example/", 50)
+	pageB.Site = "b.example"
+
+	first := &types.ReportData{
+		Results: []*types.PageResult{pageA},
+		Sites:   []types.SiteReport{{Name: "a.example"}, {Name: "b.example", Error: "timeout"}},
+	}
+	second := &types.ReportData{
+		Results: []*types.PageResult{pageB},
+		Sites:   []types.SiteReport{{Name: "a.example"}, {Name: "b.example"}},
+	}
+
+	merged, err := MergeReports([]*types.ReportData{first, second})
+	require.NoError(t, err)
+	require.Len(t, merged.Sites, 2)
+	assert.Equal(t, 1, merged.Sites[0].Summary.TotalPages)
+	assert.Equal(t, 1, merged.Sites[1].Summary.TotalPages)
+	assert.Equal(t, "timeout", merged.Sites[1].Error)
+}
+
+func TestLoadReport_ReadsSavedReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shard.json")
+	report := &types.ReportData{
+		Shard:   &types.ShardConfig{Index: 1, Count: 2},
+		Results: []*types.PageResult{mergeTestPage("https://example.com/", 75)},
+	}
+	require.NoError(t, SaveJSONReport(report, path))
+
+	loaded, err := LoadReport(path)
+	require.NoError(t, err)
+	assert.Equal(t, report.Shard, loaded.Shard)
+	require.Len(t, loaded.Results, 1)
+	assert.Equal(t, 75.0, loaded.Results[0].Mobile.Scores.Performance)
+}
@@ -0,0 +1,153 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/mattjh1/psi-map/internal/types"
+)
+
+// NormalizeURL rewrites raw according to cfg. URLs that cannot be parsed are
+// returned unchanged.
+func NormalizeURL(raw string, cfg *types.NormalizeConfig) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	if cfg.LowercaseHost {
+		u.Host = strings.ToLower(u.Host)
+	}
+	if cfg.DropFragment {
+		u.Fragment = ""
+		u.RawFragment = ""
+	}
+	if len(cfg.DropParams) > 0 && u.RawQuery != "" {
+		u.RawQuery = dropQueryParams(u.RawQuery, cfg.DropParams)
+	}
+
+	// An empty path and "/" are the same resource
+	if u.Path == "" {
+		u.Path = "/"
+		u.RawPath = ""
+	}
+	if u.Path != "/" {
+		switch cfg.TrailingSlash {
+		case types.TrailingSlashStrip:
+			u.Path = strings.TrimRight(u.Path, "/")
+			u.RawPath = strings.TrimRight(u.RawPath, "/")
+		case types.TrailingSlashAdd:
+			// Paths ending in a file name such as /feed.xml keep their form
+			if !strings.HasSuffix(u.Path, "/") && !strings.Contains(path.Base(u.Path), ".") {
+				u.Path += "/"
+				if u.RawPath != "" {
+					u.RawPath += "/"
+				}
+			}
+		}
+	}
+	return u.String()
+}
+
+// dropQueryParams removes the listed parameters from a raw query string while
+// keeping the order and encoding of the remaining ones
+func dropQueryParams(rawQuery string, drop []string) string {
+	kept := make([]string, 0)
+	for _, pair := range strings.Split(rawQuery, "&") {
+		if pair == "" {
+			continue
+		}
+		key, _, _ := strings.Cut(pair, "=")
+		if unescaped, err := url.QueryUnescape(key); err == nil {
+			key = unescaped
+		}
+		if !matchesParam(key, drop) {
+			kept = append(kept, pair)
+		}
+	}
+	return strings.Join(kept, "&")
+}
+
+// matchesParam reports whether key is listed in params; a trailing * in a
+// listed name matches any key with that prefix
+func matchesParam(key string, params []string) bool {
+	for _, p := range params {
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == p {
+			return true
+		}
+	}
+	return false
+}
+
+// URLDeduper normalizes entries one at a time and drops those whose
+// normalized URL was already seen, so it can sit in a streaming pipeline
+type URLDeduper struct {
+	cfg      *types.NormalizeConfig
+	stats    types.DedupeStats
+	order    []string
+	variants map[string][]string
+}
+
+// NewURLDeduper validates the normalization settings
+func NewURLDeduper(cfg *types.NormalizeConfig) (*URLDeduper, error) {
+	switch cfg.TrailingSlash {
+	case "", types.TrailingSlashKeep, types.TrailingSlashAdd, types.TrailingSlashStrip:
+	default:
+		return nil, fmt.Errorf("unsupported trailing slash policy: %s (supported: keep, add, strip)", cfg.TrailingSlash)
+	}
+	return &URLDeduper{cfg: cfg, variants: make(map[string][]string)}, nil
+}
+
+// Add normalizes the entry and reports whether it is the first with its URL
+func (d *URLDeduper) Add(entry types.URL) (types.URL, bool) {
+	original := entry.Loc
+	normalized := NormalizeURL(original, d.cfg)
+	d.stats.Total++
+	if normalized != original {
+		d.stats.Rewritten++
+	}
+
+	_, seen := d.variants[normalized]
+	d.variants[normalized] = append(d.variants[normalized], original)
+	if seen {
+		return entry, false
+	}
+
+	d.order = append(d.order, normalized)
+	entry.Loc = normalized
+	entry.Alternates = normalizeAlternates(entry.Alternates, d.cfg)
+	return entry, true
+}
+
+// Stats returns the counts so far, with duplicate groups in first-seen order
+func (d *URLDeduper) Stats() *types.DedupeStats {
+	stats := d.stats
+	stats.Unique = len(d.order)
+	stats.Merged = stats.Total - stats.Unique
+	stats.Duplicates = nil
+	for _, u := range d.order {
+		if originals := d.variants[u]; len(originals) > 1 {
+			stats.Duplicates = append(stats.Duplicates, types.DuplicateGroup{URL: u, Variants: originals})
+		}
+	}
+	return &stats
+}
+
+// normalizeAlternates returns a copy of the alternates with normalized hrefs
+func normalizeAlternates(alternates []types.Alternate, cfg *types.NormalizeConfig) []types.Alternate {
+	if len(alternates) == 0 {
+		return alternates
+	}
+	normalized := make([]types.Alternate, len(alternates))
+	for i, alt := range alternates {
+		alt.Href = NormalizeURL(alt.Href, cfg)
+		normalized[i] = alt
+	}
+	return normalized
+}
@@ -0,0 +1,94 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	cfg := &types.NormalizeConfig{
+		LowercaseHost: true,
+		DropFragment:  true,
+		DropParams:    []string{"utm_*", "gclid"},
+	}
+
+	tests := []struct {
+		name          string
+		trailingSlash string
+		input         string
+		expected      string
+	}{
+		{"lowercase host", types.TrailingSlashKeep, "https://Example.COM/About", "https://example.com/About"},
+		{"drop fragment", types.TrailingSlashKeep, "https://example.com/a#top", "https://example.com/a"},
+		{"drop params keeps others in order", types.TrailingSlashKeep, "https://example.com/a?b=2&utm_source=x&a=1&gclid=y", "https://example.com/a?b=2&a=1"},
+		{"drop all params", types.TrailingSlashKeep, "https://example.com/a?utm_medium=email", "https://example.com/a"},
+		{"empty path becomes root", types.TrailingSlashStrip, "https://example.com", "https://example.com/"},
+		{"keep slash", types.TrailingSlashKeep, "https://example.com/a/", "https://example.com/a/"},
+		{"strip slash", types.TrailingSlashStrip, "https://example.com/a/", "https://example.com/a"},
+		{"strip keeps root", types.TrailingSlashStrip, "https://example.com/", "https://example.com/"},
+		{"add slash", types.TrailingSlashAdd, "https://example.com/a", "https://example.com/a/"},
+		{"add skips files", types.TrailingSlashAdd, "https://example.com/feed.xml", "https://example.com/feed.xml"},
+		{"add keeps query", types.TrailingSlashAdd, "https://example.com/a?page=2", "https://example.com/a/?page=2"},
+		{"not a URL", types.TrailingSlashStrip, "not a url/", "not a url/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := *cfg
+			c.TrailingSlash = tt.trailingSlash
+			assert.Equal(t, tt.expected, NormalizeURL(tt.input, &c))
+		})
+	}
+}
+
+func TestURLDeduper(t *testing.T) {
+	entries := []types.URL{
+		{Loc: "https://example.com/a", Priority: "0.9"},
+		{Loc: "https://example.com/b"},
+		{Loc: "https://EXAMPLE.com/a/", Priority: "0.1"},
+		{Loc: "https://example.com/a?utm_source=news#top"},
+		{Loc: "https://example.com/b"},
+	}
+	cfg := &types.NormalizeConfig{
+		TrailingSlash: types.TrailingSlashStrip,
+		LowercaseHost: true,
+		DropFragment:  true,
+		DropParams:    []string{"utm_*"},
+	}
+
+	deduper, err := NewURLDeduper(cfg)
+	require.NoError(t, err)
+	var unique []types.URL
+	for _, entry := range entries {
+		if normalized, ok := deduper.Add(entry); ok {
+			unique = append(unique, normalized)
+		}
+	}
+	stats := deduper.Stats()
+	assert.Equal(t, []types.URL{
+		{Loc: "https://example.com/a", Priority: "0.9"},
+		{Loc: "https://example.com/b"},
+	}, unique, "first occurrence should win and keep its metadata")
+
+	assert.Equal(t, 5, stats.Total)
+	assert.Equal(t, 2, stats.Unique)
+	assert.Equal(t, 3, stats.Merged)
+	assert.Equal(t, 2, stats.Rewritten)
+	assert.Equal(t, []types.DuplicateGroup{
+		{URL: "https://example.com/a", Variants: []string{
+			"https://example.com/a",
+			"https://EXAMPLE.com/a/",
+			"https://example.com/a?utm_source=news#top",
+		}},
+		{URL: "https://example.com/b", Variants: []string{"https://example.com/b", "https://example.com/b"}},
+	}, stats.Duplicates)
+}
+
+func TestNewURLDeduper_InvalidPolicy(t *testing.T) {
+	_, err := NewURLDeduper(&types.NormalizeConfig{TrailingSlash: "sometimes"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported trailing slash policy")
+}
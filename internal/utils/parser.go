@@ -8,14 +8,14 @@ import (
 	"net/http"
 	"net/url"
 	"strings"
-	"time"
 
+	"github.com/mattjh1/psi-map/internal/constants"
 	"github.com/mattjh1/psi-map/internal/logger"
 	"github.com/mattjh1/psi-map/internal/types"
 	"github.com/mattjh1/psi-map/internal/utils/validate"
 )
 
-func fetchRemoteSitemap(input string) (io.ReadCloser, error) {
+func fetchRemoteSitemap(ctx context.Context, input string) (io.ReadCloser, error) {
 	log := logger.GetLogger()
 	parsedURL, err := url.Parse(input)
 	if err != nil {
@@ -26,16 +26,18 @@ func fetchRemoteSitemap(input string) (io.ReadCloser, error) {
 		return nil, fmt.Errorf("unsupported URL scheme: %s", parsedURL.Scheme)
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// The body is read while streaming, so the timeout covers the whole download
+	ctx, cancel := context.WithTimeout(ctx, constants.SitemapFetchTimeout)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsedURL.String(), http.NoBody)
 	if err != nil {
+		cancel()
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
+		cancel()
 		return nil, fmt.Errorf("failed to fetch sitemap: %w", err)
 	}
 
@@ -43,42 +45,170 @@ func fetchRemoteSitemap(input string) (io.ReadCloser, error) {
 		if err := resp.Body.Close(); err != nil {
 			log.Error("Failed to close response body: %v", err)
 		}
+		cancel()
 		return nil, fmt.Errorf("non-200 status: %d", resp.StatusCode)
 	}
 
-	return resp.Body, nil
+	return &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}, nil
+}
+
+// cancelOnClose releases the request context once the body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
+
+// isRemoteInput reports whether the input refers to a URL rather than a local file
+func isRemoteInput(input string) bool {
+	return strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://")
 }
 
 // ParseSitemap takes a path or URL to a sitemap and returns a slice of URLs
 func ParseSitemap(input string) ([]string, error) {
-	var reader io.ReadCloser
+	entries, err := ParseSitemapEntries(input)
+	if err != nil {
+		return nil, err
+	}
 
-	if strings.HasPrefix(input, "http://") || strings.HasPrefix(input, "https://") {
-		body, err := fetchRemoteSitemap(input)
-		if err != nil {
-			return nil, err
+	urls := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		urls = append(urls, entry.Loc)
+	}
+	return urls, nil
+}
+
+// ParseSitemapEntries takes a path or URL to a sitemap and returns its URL
+// entries, including the optional lastmod, priority and hreflang alternate fields
+func ParseSitemapEntries(input string) ([]types.URL, error) {
+	stream, errc := StreamSitemapEntries(context.Background(), input)
+	entries := make([]types.URL, 0)
+	for entry := range stream {
+		entries = append(entries, entry)
+	}
+	if err := <-errc; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// StreamSitemapEntries parses the sitemap token by token and sends each URL
+// entry on the returned channel as soon as it is read. Sitemap indexes are
+// followed into their child sitemaps. The entry channel is closed when parsing
+// ends, after which the error channel yields the parse error, if any. Once
+// ctx is cancelled, parsing stops and the sitemap is closed, so a consumer
+// may stop reading at any time.
+func StreamSitemapEntries(ctx context.Context, input string) (<-chan types.URL, <-chan error) {
+	out := make(chan types.URL, constants.SitemapStreamBuffer)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		defer close(out)
+		errc <- streamSitemap(ctx, input, out, 0)
+	}()
+	return out, errc
+}
+
+// streamSitemap sends the entries of one sitemap document, recursing into the
+// child sitemaps of an index
+func streamSitemap(ctx context.Context, input string, out chan<- types.URL, depth int) error {
+	reader, err := openSitemap(ctx, input)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	decoder := xml.NewDecoder(reader)
+	sawRoot := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
 		}
-		defer body.Close()
-		reader = body
-	} else {
-		file, err := validate.SafeOpenFile(input)
 		if err != nil {
-			return nil, fmt.Errorf("failed to open file: %w", err)
+			return fmt.Errorf("failed to parse XML: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		sawRoot = true
+
+		switch start.Name.Local {
+		case "url":
+			var u types.URL
+			if err := decoder.DecodeElement(&u, &start); err != nil {
+				return fmt.Errorf("failed to parse XML: %w", err)
+			}
+			select {
+			case out <- cleanEntry(u):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		case "sitemap":
+			var child struct {
+				Loc string `xml:"loc"`
+			}
+			if err := decoder.DecodeElement(&child, &start); err != nil {
+				return fmt.Errorf("failed to parse XML: %w", err)
+			}
+			loc := strings.TrimSpace(child.Loc)
+			if loc == "" {
+				continue
+			}
+			// A remote index must not make psi-map read local files
+			if isRemoteInput(input) && !isRemoteInput(loc) {
+				return fmt.Errorf("child sitemap %s of remote sitemap index %s is not an http(s) URL", loc, input)
+			}
+			if depth >= constants.MaxSitemapIndexDepth {
+				return fmt.Errorf("sitemap index nested deeper than %d levels at %s", constants.MaxSitemapIndexDepth, loc)
+			}
+			if err := streamSitemap(ctx, loc, out, depth+1); err != nil {
+				return fmt.Errorf("failed to read child sitemap %s: %w", loc, err)
+			}
 		}
-		defer file.Close()
-		reader = file
 	}
 
-	decoder := xml.NewDecoder(reader)
-	var sitemap types.Sitemap
-	if err := decoder.Decode(&sitemap); err != nil {
-		return nil, fmt.Errorf("failed to parse XML: %w", err)
+	if !sawRoot {
+		return fmt.Errorf("failed to parse XML: %w", io.ErrUnexpectedEOF)
 	}
+	return nil
+}
 
-	urls := make([]string, 0, len(sitemap.URLs))
-	for _, u := range sitemap.URLs {
-		urls = append(urls, strings.TrimSpace(u.Loc))
+// openSitemap opens a local or remote sitemap for reading
+func openSitemap(ctx context.Context, input string) (io.ReadCloser, error) {
+	if isRemoteInput(input) {
+		return fetchRemoteSitemap(ctx, input)
 	}
+	file, err := validate.SafeOpenFile(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	return file, nil
+}
 
-	return urls, nil
+// cleanEntry trims the entry's fields and keeps only usable hreflang alternates
+func cleanEntry(u types.URL) types.URL {
+	entry := types.URL{
+		Loc:      strings.TrimSpace(u.Loc),
+		Lastmod:  strings.TrimSpace(u.Lastmod),
+		Priority: strings.TrimSpace(u.Priority),
+	}
+	for _, alt := range u.Alternates {
+		href := strings.TrimSpace(alt.Href)
+		if href == "" || !strings.EqualFold(strings.TrimSpace(alt.Rel), "alternate") {
+			continue
+		}
+		entry.Alternates = append(entry.Alternates, types.Alternate{
+			Rel:      "alternate",
+			Hreflang: strings.TrimSpace(alt.Hreflang),
+			Href:     href,
+		})
+	}
+	return entry
 }
@@ -1,14 +1,31 @@
 package utils
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/mattjh1/psi-map/internal/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// writeTempSitemap writes the XML content to a temporary sitemap file
+func writeTempSitemap(t *testing.T, content string) string {
+	t.Helper()
+	tmpFile, err := os.CreateTemp(t.TempDir(), "sitemap-*.xml")
+	require.NoError(t, err)
+	_, err = tmpFile.WriteString(content)
+	require.NoError(t, err)
+	require.NoError(t, tmpFile.Close())
+	return tmpFile.Name()
+}
+
 func TestParseSitemap_LocalFile(t *testing.T) {
 	xmlContent := `
 		<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
@@ -57,3 +74,140 @@ func TestParseSitemap_RemoteFile(t *testing.T) {
 	assert.Len(t, urls, 1)
 	assert.Equal(t, "http://example.com/remote1", urls[0])
 }
+
+func TestParseSitemapEntries_Metadata(t *testing.T) {
+	path := writeTempSitemap(t, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+		<url><loc> https://example.com/a </loc><lastmod>2024-05-01</lastmod><priority>0.8</priority></url>
+		<url><loc>https://example.com/b</loc></url>
+	</urlset>`)
+
+	entries, err := ParseSitemapEntries(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, types.URL{Loc: "https://example.com/a", Lastmod: "2024-05-01", Priority: "0.8"}, entries[0])
+	assert.Equal(t, 0.8, entryPriority(entries[0]))
+	assert.Equal(t, 0.5, entryPriority(entries[1]))
+	assert.True(t, entryLastmod(entries[1]).IsZero())
+}
+
+func TestParseSitemapEntries_Alternates(t *testing.T) {
+	path := writeTempSitemap(t, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:xhtml="http://www.w3.org/1999/xhtml">
+		<url>
+			<loc>https://example.com/en/</loc>
+			<xhtml:link rel="alternate" hreflang="de" href="https://example.com/de/"/>
+			<xhtml:link rel="alternate" hreflang="en" href=" https://example.com/en/ "/>
+			<xhtml:link rel="canonical" href="https://example.com/en/"/>
+		</url>
+	</urlset>`)
+
+	entries, err := ParseSitemapEntries(path)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, []types.Alternate{
+		{Rel: "alternate", Hreflang: "de", Href: "https://example.com/de/"},
+		{Rel: "alternate", Hreflang: "en", Href: "https://example.com/en/"},
+	}, entries[0].Alternates)
+}
+
+func TestStreamSitemapEntries(t *testing.T) {
+	path := writeTempSitemap(t, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+		<url><loc>https://example.com/1</loc></url>
+		<url><loc>https://example.com/2</loc></url>
+	</urlset>`)
+
+	stream, errc := StreamSitemapEntries(context.Background(), path)
+	locs := make([]string, 0)
+	for entry := range stream {
+		locs = append(locs, entry.Loc)
+	}
+	require.NoError(t, <-errc)
+	assert.Equal(t, []string{"https://example.com/1", "https://example.com/2"}, locs)
+}
+
+func TestStreamSitemapEntries_Index(t *testing.T) {
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	mux.HandleFunc("/sitemap-index.xml", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+			<sitemap><loc>` + server.URL + `/posts.xml</loc></sitemap>
+			<sitemap><loc>` + server.URL + `/pages.xml</loc></sitemap>
+		</sitemapindex>`))
+	})
+	mux.HandleFunc("/posts.xml", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+			<url><loc>https://example.com/post-1</loc></url>
+			<url><loc>https://example.com/post-2</loc></url>
+		</urlset>`))
+	})
+	mux.HandleFunc("/pages.xml", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+			<url><loc>https://example.com/about</loc></url>
+		</urlset>`))
+	})
+
+	urls, err := ParseSitemap(server.URL + "/sitemap-index.xml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"https://example.com/post-1",
+		"https://example.com/post-2",
+		"https://example.com/about",
+	}, urls)
+}
+
+func TestStreamSitemapEntries_RemoteIndexCannotOpenLocalFiles(t *testing.T) {
+	local := writeTempSitemap(t, `<urlset><url><loc>https://example.com/secret</loc></url></urlset>`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<sitemapindex><sitemap><loc>` + local + `</loc></sitemap></sitemapindex>`))
+	}))
+	defer server.Close()
+
+	_, err := ParseSitemap(server.URL)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not an http(s) URL")
+}
+
+func TestStreamSitemapEntries_StopsWhenCancelled(t *testing.T) {
+	var doc strings.Builder
+	doc.WriteString("<urlset>")
+	for i := range 1000 {
+		fmt.Fprintf(&doc, "<url><loc>https://example.com/%d</loc></url>", i)
+	}
+	doc.WriteString("</urlset>")
+	path := writeTempSitemap(t, doc.String())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, errc := StreamSitemapEntries(ctx, path)
+	<-stream
+	cancel()
+
+	// The producer gives up on the entries nobody reads and closes both channels
+	select {
+	case err := <-errc:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("parser kept blocking after cancellation")
+	}
+}
+
+func TestStreamSitemapEntries_MalformedAfterEntries(t *testing.T) {
+	path := writeTempSitemap(t, `<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+		<url><loc>https://example.com/1</loc></url>
+		<url><loc>https://example.com/2`)
+
+	stream, errc := StreamSitemapEntries(context.Background(), path)
+	count := 0
+	for range stream {
+		count++
+	}
+	assert.Equal(t, 1, count, "entries before the syntax error should already be sent")
+	err := <-errc
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse XML")
+}
+
+func TestParseSitemap_Empty(t *testing.T) {
+	_, err := ParseSitemap(writeTempSitemap(t, ""))
+	assert.Error(t, err)
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/mattjh1/psi-map/internal/utils/validate"
+)
+
+// GroupURLSections groups URLs by top-level path section, sorted by section.
+// URLs keep their order within a section.
+func GroupURLSections(urls []string) []types.URLSection {
+	index := make(map[string]int)
+	var sections []types.URLSection
+	for _, raw := range urls {
+		section := "/"
+		if u, err := url.Parse(raw); err == nil {
+			section = pathSection(u.Path)
+		}
+		i, ok := index[section]
+		if !ok {
+			i = len(sections)
+			index[section] = i
+			sections = append(sections, types.URLSection{Section: section})
+		}
+		sections[i].URLs = append(sections[i].URLs, raw)
+	}
+	sort.SliceStable(sections, func(i, j int) bool {
+		return sections[i].Section < sections[j].Section
+	})
+	return sections
+}
+
+// SectionIncludeRules returns the include rules matching a section and
+// everything below it
+func SectionIncludeRules(section string) []string {
+	if section == "/" {
+		return []string{"/"}
+	}
+	return []string{section, section + "/**"}
+}
+
+// PageIncludeRule returns an include rule matching exactly one URL
+func PageIncludeRule(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || strings.ContainsAny(u.Path, "*?") {
+		return regexRulePrefix + "^" + regexp.QuoteMeta(rawURL) + "$"
+	}
+	if u.Path == "" {
+		return "/"
+	}
+	return u.Path
+}
+
+// LoadIncludeList reads include rules from a file with one rule per line.
+// Blank lines and lines starting with # are ignored.
+func LoadIncludeList(path string) ([]string, error) {
+	validPath, err := validate.ValidateInputPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid include list path: %w", err)
+	}
+	file, err := os.Open(validPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open include list: %w", err)
+	}
+	defer file.Close()
+
+	var rules []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		rules = append(rules, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read include list: %w", err)
+	}
+	return rules, nil
+}
+
+// SaveIncludeList writes include rules to a file that --include-file can read
+// and returns its path. Paths without an extension get .txt.
+func SaveIncludeList(path string, rules []string) (string, error) {
+	components := validate.SplitFilePath(path)
+	if components.Extension == "" {
+		components.Extension = ".txt"
+	}
+	file, savedPath, err := validate.SafeCreateFile(components.Dir, components.Name, components.Extension)
+	if err != nil {
+		return "", fmt.Errorf("failed to create include list: %w", err)
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	fmt.Fprintln(w, "# psi-map include list, use with --include-file")
+	for _, rule := range rules {
+		fmt.Fprintln(w, rule)
+	}
+	if err := w.Flush(); err != nil {
+		return "", fmt.Errorf("failed to save include list %s: %w", savedPath, err)
+	}
+	return savedPath, nil
+}
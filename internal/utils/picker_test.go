@@ -0,0 +1,59 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupURLSections(t *testing.T) {
+	sections := GroupURLSections([]string{
+		"https://example.com/blog/b",
+		"https://example.com/",
+		"https://example.com/about",
+		"https://example.com/blog/a",
+	})
+
+	assert.Equal(t, []types.URLSection{
+		{Section: "/", URLs: []string{"https://example.com/"}},
+		{Section: "/about", URLs: []string{"https://example.com/about"}},
+		{Section: "/blog", URLs: []string{"https://example.com/blog/b", "https://example.com/blog/a"}},
+	}, sections)
+}
+
+func TestIncludeRules(t *testing.T) {
+	assert.Equal(t, []string{"/"}, SectionIncludeRules("/"))
+	assert.Equal(t, []string{"/blog", "/blog/**"}, SectionIncludeRules("/blog"))
+
+	assert.Equal(t, "/blog/a", PageIncludeRule("https://example.com/blog/a?page=2"))
+	assert.Equal(t, "/", PageIncludeRule("https://example.com"))
+	assert.Equal(t, `re:^https://example\.com/a\*b$`, PageIncludeRule("https://example.com/a*b"))
+
+	// The rules select exactly what was picked
+	filter, err := NewURLFilter(append(SectionIncludeRules("/blog"), PageIncludeRule("https://example.com/about")), nil)
+	require.NoError(t, err)
+	assert.True(t, filter.Keep("https://example.com/blog"))
+	assert.True(t, filter.Keep("https://example.com/blog/2024/post"))
+	assert.True(t, filter.Keep("https://example.com/about"))
+	assert.False(t, filter.Keep("https://example.com/about/team"))
+	assert.False(t, filter.Keep("https://example.com/blogger"))
+}
+
+func TestSaveAndLoadIncludeList(t *testing.T) {
+	dir := t.TempDir()
+	saved, err := SaveIncludeList(filepath.Join(dir, "picked"), []string{"/blog", "/blog/**"})
+	require.NoError(t, err)
+	assert.Equal(t, ".txt", filepath.Ext(saved))
+
+	rules, err := LoadIncludeList(saved)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/blog", "/blog/**"}, rules)
+}
+
+func TestLoadIncludeList_Missing(t *testing.T) {
+	_, err := LoadIncludeList(filepath.Join(t.TempDir(), "missing.txt"))
+	require.ErrorContains(t, err, "invalid include list path")
+}
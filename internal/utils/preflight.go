@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+
+	"github.com/mattjh1/psi-map/internal/constants"
+	"github.com/mattjh1/psi-map/internal/types"
+)
+
+// lookupIP resolves hostnames for the private address check, replaceable in tests
+var lookupIP = net.DefaultResolver.LookupIPAddr
+
+// cgnatRange is the carrier-grade NAT range, unreachable from PSI like private ranges
+var cgnatRange = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// Preflight checks that URLs are reachable HTML pages before PSI quota is spent
+// on them, and remembers the ones it skipped
+type Preflight struct {
+	concurrency int
+
+	mu      sync.Mutex
+	seq     int
+	skipped []preflightSkip
+}
+
+type preflightSkip struct {
+	seq int
+	url types.SkippedURL
+}
+
+// NewPreflight returns a preflight that runs up to concurrency checks at once
+func NewPreflight(concurrency int) *Preflight {
+	return &Preflight{concurrency: max(1, concurrency)}
+}
+
+// Stream checks the URLs from in and forwards the reachable ones, closing the
+// returned channel once in is closed and every check has finished
+func (p *Preflight) Stream(in <-chan string) <-chan string {
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, p.concurrency)
+		for rawURL := range in {
+			p.mu.Lock()
+			seq := p.seq
+			p.seq++
+			p.mu.Unlock()
+
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(seq int, rawURL string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				if skip := CheckReachable(rawURL); skip != nil {
+					p.mu.Lock()
+					p.skipped = append(p.skipped, preflightSkip{seq: seq, url: *skip})
+					p.mu.Unlock()
+					return
+				}
+				out <- rawURL
+			}(seq, rawURL)
+		}
+		wg.Wait()
+	}()
+	return out
+}
+
+// Skipped returns the skipped URLs in the order they were received
+func (p *Preflight) Skipped() []types.SkippedURL {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	sort.Slice(p.skipped, func(i, j int) bool { return p.skipped[i].seq < p.skipped[j].seq })
+	skipped := make([]types.SkippedURL, 0, len(p.skipped))
+	for _, s := range p.skipped {
+		skipped = append(skipped, s.url)
+	}
+	return skipped
+}
+
+// CheckReachable returns why PSI would fail on the URL, or nil when it looks
+// like a reachable HTML page
+func CheckReachable(rawURL string) *types.SkippedURL {
+	skip := func(format string, args ...any) *types.SkippedURL {
+		return &types.SkippedURL{URL: rawURL, Reason: fmt.Sprintf(format, args...)}
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return skip("invalid URL")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), constants.StatusCheckTimeout)
+	defer cancel()
+	addrs, err := lookupIP(ctx, u.Hostname())
+	if err != nil {
+		return skip("DNS lookup failed: %v", err)
+	}
+	for _, addr := range addrs {
+		if isPrivateIP(addr.IP) {
+			return skip("resolves to private address %s", addr.IP)
+		}
+	}
+
+	resp, err := statusRequest(http.MethodHead, rawURL)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp.Body.Close()
+		resp, err = statusRequest(http.MethodGet, rawURL)
+	}
+	if err != nil {
+		return skip("unreachable: %v", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		target := resp.Header.Get("Location")
+		if loc, err := u.Parse(target); err == nil {
+			target = loc.String()
+		}
+		result := skip("redirects to %s", target)
+		result.StatusCode = resp.StatusCode
+		return result
+	case resp.StatusCode >= 400:
+		result := skip("HTTP %d", resp.StatusCode)
+		result.StatusCode = resp.StatusCode
+		return result
+	}
+
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err == nil &&
+			mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+			return skip("non-HTML content type %s", mediaType)
+		}
+	}
+	return nil
+}
+
+// isPrivateIP reports whether PSI's public crawlers could not reach the address
+func isPrivateIP(ip net.IP) bool {
+	return ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsUnspecified() || cgnatRange.Contains(ip)
+}
@@ -0,0 +1,106 @@
+package utils
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withPublicDNS makes every host resolve to a public address so httptest
+// servers on loopback pass the private address check
+func withPublicDNS(t *testing.T) {
+	t.Helper()
+	original := lookupIP
+	lookupIP = func(ctx context.Context, host string) ([]net.IPAddr, error) {
+		return []net.IPAddr{{IP: net.ParseIP("93.184.216.34")}}, nil
+	}
+	t.Cleanup(func() { lookupIP = original })
+}
+
+func preflightServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/page", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+	})
+	mux.HandleFunc("/moved", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/page", http.StatusFound)
+	})
+	mux.HandleFunc("/report.pdf", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/pdf")
+	})
+	mux.HandleFunc("/broken", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCheckReachable(t *testing.T) {
+	withPublicDNS(t)
+	server := preflightServer(t)
+
+	assert.Nil(t, CheckReachable(server.URL+"/page"))
+	assert.Nil(t, CheckReachable(server.URL+"/no-head"), "GET should be used when HEAD is not allowed")
+
+	assert.Equal(t, &types.SkippedURL{URL: server.URL + "/moved", Reason: "redirects to " + server.URL + "/page", StatusCode: http.StatusFound},
+		CheckReachable(server.URL+"/moved"))
+	assert.Equal(t, &types.SkippedURL{URL: server.URL + "/missing", Reason: "HTTP 404", StatusCode: http.StatusNotFound},
+		CheckReachable(server.URL+"/missing"))
+	assert.Equal(t, "HTTP 500", CheckReachable(server.URL+"/broken").Reason)
+	assert.Equal(t, "non-HTML content type application/pdf", CheckReachable(server.URL+"/report.pdf").Reason)
+}
+
+func TestCheckReachable_PrivateAddress(t *testing.T) {
+	server := preflightServer(t)
+
+	skip := CheckReachable(server.URL + "/page")
+	require.NotNil(t, skip)
+	assert.Equal(t, "resolves to private address 127.0.0.1", skip.Reason)
+
+	for _, ip := range []string{"10.1.2.3", "192.168.0.1", "172.16.5.4", "169.254.1.1", "100.64.0.1", "::1", "fd00::1"} {
+		assert.True(t, isPrivateIP(net.ParseIP(ip)), ip)
+	}
+	assert.False(t, isPrivateIP(net.ParseIP("8.8.8.8")))
+}
+
+func TestPreflight_Stream(t *testing.T) {
+	withPublicDNS(t)
+	server := preflightServer(t)
+
+	in := make(chan string)
+	preflight := NewPreflight(2)
+	out := preflight.Stream(in)
+	go func() {
+		defer close(in)
+		for _, path := range []string{"/page", "/broken", "/no-head", "/report.pdf", "/moved"} {
+			in <- server.URL + path
+		}
+	}()
+
+	passed := make([]string, 0)
+	for u := range out {
+		passed = append(passed, u)
+	}
+	assert.ElementsMatch(t, []string{server.URL + "/page", server.URL + "/no-head"}, passed)
+
+	skipped := preflight.Skipped()
+	require.Len(t, skipped, 3)
+	assert.Equal(t, server.URL+"/broken", skipped[0].URL, "skipped URLs should keep their input order")
+	assert.Equal(t, server.URL+"/report.pdf", skipped[1].URL)
+	assert.Equal(t, server.URL+"/moved", skipped[2].URL)
+}
@@ -3,8 +3,10 @@ package utils
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -18,6 +20,9 @@ import (
 // Package-level HTTP client for testability
 var httpClient = &http.Client{}
 
+// PSICategories are the Lighthouse categories requested from PSI
+var PSICategories = []string{"performance", "accessibility", "best-practices", "seo"}
+
 // FetchScore retrieves comprehensive performance data from the PSI API
 func FetchScoreImpl(ctx context.Context, pageURL, strategy string) types.Result {
 	start := time.Now()
@@ -26,10 +31,9 @@ func FetchScoreImpl(ctx context.Context, pageURL, strategy string) types.Result
 	params := url.Values{}
 	params.Add("url", pageURL)
 	params.Add("strategy", strategy)
-	params.Add("category", "performance")
-	params.Add("category", "accessibility")
-	params.Add("category", "best-practices")
-	params.Add("category", "seo")
+	for _, category := range PSICategories {
+		params.Add("category", category)
+	}
 	if apiKey != "" {
 		params.Add("key", apiKey)
 	}
@@ -62,7 +66,7 @@ func FetchScoreImpl(ctx context.Context, pageURL, strategy string) types.Result
 		return types.Result{
 			URL:      pageURL,
 			Strategy: strategy,
-			Error:    fmt.Errorf("API error: status %d", resp.StatusCode),
+			Error:    &APIError{StatusCode: resp.StatusCode},
 			Elapsed:  time.Since(start),
 		}
 	}
@@ -90,6 +94,29 @@ func FetchScoreImpl(ctx context.Context, pageURL, strategy string) types.Result
 	return extractResultData(&data, pageURL, strategy, time.Since(start))
 }
 
+// APIError is a non-200 response from the PSI API
+type APIError struct {
+	StatusCode int
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error: status %d", e.StatusCode)
+}
+
+// IsRetryable reports whether a failed PSI request may succeed when tried
+// again: rate limits, server errors and network timeouts
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 // If you want to provide a convenience function without context for backward compatibility:
 func FetchScoreWithTimeout(pageURL, strategy string, timeout time.Duration) types.Result {
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
@@ -101,6 +128,14 @@ func FetchScore(pageURL, strategy string) types.Result {
 	return FetchScoreWithTimeout(pageURL, strategy, constants.ReadHeaderTimeout)
 }
 
+// FetchScoreContext is FetchScore bound to a parent context, so the request
+// stops as soon as ctx is cancelled
+func FetchScoreContext(ctx context.Context, pageURL, strategy string) types.Result {
+	ctx, cancel := context.WithTimeout(ctx, constants.ReadHeaderTimeout)
+	defer cancel()
+	return FetchScoreImpl(ctx, pageURL, strategy)
+}
+
 // extractResultData processes the PSI response into our Result struct
 func extractResultData(data *psi.PSIResponse, pageURL, strategy string, elapsed time.Duration) types.Result {
 	result := types.Result{
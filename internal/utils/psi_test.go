@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -163,10 +165,11 @@ func TestFetchScore_HTTPErrors(t *testing.T) {
 		name       string
 		statusCode int
 		expected   string
+		retryable  bool
 	}{
-		{"BadRequest", 400, "API error: status 400"},
-		{"RateLimit", 429, "API error: status 429"},
-		{"ServerError", 500, "API error: status 500"},
+		{"BadRequest", 400, "API error: status 400", false},
+		{"RateLimit", 429, "API error: status 429", true},
+		{"ServerError", 500, "API error: status 500", true},
 	}
 
 	for _, tc := range testCases {
@@ -181,10 +184,18 @@ func TestFetchScore_HTTPErrors(t *testing.T) {
 			result := FetchScore("https://example.com", "mobile")
 			assert.Error(t, result.Error)
 			assert.Contains(t, result.Error.Error(), tc.expected)
+			assert.Equal(t, tc.retryable, IsRetryable(result.Error))
 		})
 	}
 }
 
+func TestIsRetryable_NetworkErrors(t *testing.T) {
+	assert.False(t, IsRetryable(nil))
+	assert.False(t, IsRetryable(fmt.Errorf("request failed: %w", context.Canceled)))
+	assert.False(t, IsRetryable(fmt.Errorf("request failed: %w", errors.New("connection refused"))))
+	assert.True(t, IsRetryable(fmt.Errorf("request failed: %w", context.DeadlineExceeded)))
+}
+
 func TestFetchScore_InvalidJSON(t *testing.T) {
 	mockResp := &http.Response{
 		StatusCode: 200,
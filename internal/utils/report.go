@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/mattjh1/psi-map/internal/logger"
@@ -15,7 +16,8 @@ import (
 // serverGenerateHTMLFile is a package-level variable to allow mocking in tests
 var serverGenerateHTMLFile = server.GenerateHTMLFile
 
-func SaveJSONReport(results []*types.PageResult, filename string) error {
+// SaveJSONReport writes the report as indented JSON to the given file
+func SaveJSONReport(report *types.ReportData, filename string) error {
 	components := validate.SplitFilePath(filename)
 
 	// Use the secure file creation function
@@ -27,25 +29,25 @@ func SaveJSONReport(results []*types.PageResult, filename string) error {
 
 	encoder := json.NewEncoder(file)
 	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(results); err != nil {
+	if err := encoder.Encode(report); err != nil {
 		return fmt.Errorf("failed to save JSON report %s: %w", filename, err)
 	}
 	return nil
 }
 
-// SaveJSONToStdout outputs JSON results to stdout for piping
-func SaveJSONToStdout(results []*types.PageResult) error {
+// SaveJSONToStdout outputs the JSON report to stdout for piping
+func SaveJSONToStdout(report *types.ReportData) error {
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(results); err != nil {
+	if err := encoder.Encode(report); err != nil {
 		return fmt.Errorf("failed to encode JSON to stdout: %w", err)
 	}
 	return nil
 }
 
 // SaveHTMLReport generates HTML report using the server's template and functions
-func SaveHTMLReport(results []*types.PageResult, filename string) error {
-	if err := serverGenerateHTMLFile(results, filename); err != nil {
+func SaveHTMLReport(report *types.ReportData, filename string) error {
+	if err := serverGenerateHTMLFile(report, filename); err != nil {
 		return fmt.Errorf("failed to generate HTML report %s: %w", filename, err)
 	}
 	return nil
@@ -91,6 +93,37 @@ func PrintSummary(results []*types.PageResult, elapsed time.Duration) {
 	log.Info("Total Time Elapsed: %v", elapsed)
 }
 
+// PrintFilterStats logs how many URLs the include/exclude rules removed
+func PrintFilterStats(stats *types.FilterStats) {
+	log := logger.GetLogger()
+	log.Tagged("FILTER", "Kept %d of %d URL(s), excluded %d", "🔎", stats.Kept, stats.Total, stats.Excluded)
+	if stats.NotIncluded > 0 {
+		log.Tagged("FILTER", "  %d URL(s) matched no include rule", "", stats.NotIncluded)
+	}
+	for _, rule := range stats.Rules {
+		log.Tagged("FILTER", "  %d URL(s) excluded by %s", "", rule.Excluded, rule.Rule)
+	}
+}
+
+// PrintDedupeStats logs how many URLs normalization merged
+func PrintDedupeStats(stats *types.DedupeStats) {
+	log := logger.GetLogger()
+	log.Tagged("DEDUPE", "%d unique URL(s) from %d, merged %d duplicate(s), rewrote %d", "🧹",
+		stats.Unique, stats.Total, stats.Merged, stats.Rewritten)
+	for _, group := range stats.Duplicates {
+		log.Tagged("DEDUPE", "  %s <- %s", "", group.URL, strings.Join(group.Variants, ", "))
+	}
+}
+
+// PrintSkippedURLs logs the URLs the preflight check kept away from PSI
+func PrintSkippedURLs(skipped []types.SkippedURL) {
+	log := logger.GetLogger()
+	log.Tagged("PREFLIGHT", "Skipped %d URL(s) that PSI could not analyze", "🚧", len(skipped))
+	for _, s := range skipped {
+		log.Tagged("PREFLIGHT", "  %s: %s", "", s.URL, s.Reason)
+	}
+}
+
 // formatCategoryName converts snake_case to Title Case
 func formatCategoryName(s string) string {
 	switch s {
@@ -106,3 +139,43 @@ func formatCategoryName(s string) string {
 		return s
 	}
 }
+
+// resultsByURL indexes results by analyzed URL and, for rewritten URLs, by
+// their original sitemap URL
+func resultsByURL(results []*types.PageResult) map[string]*types.PageResult {
+	byURL := make(map[string]*types.PageResult, len(results))
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		byURL[result.URL] = result
+		if result.OriginalURL != "" {
+			byURL[result.OriginalURL] = result
+		}
+	}
+	return byURL
+}
+
+// PrintPortfolio prints one row per site of a multi-site run
+func PrintPortfolio(sites []types.SiteReport) {
+	ui := logger.GetLogger().UI()
+	ui.Header("PORTFOLIO")
+
+	rows := make([][]string, 0, len(sites))
+	for i := range sites {
+		site := &sites[i]
+		row := []string{site.Name, fmt.Sprint(site.Summary.TotalPages), fmt.Sprint(site.Summary.FailedPages)}
+		for _, cat := range []string{"performance", "accessibility", "best_practices", "seo"} {
+			if score, ok := site.Summary.AverageScores[cat]; ok {
+				row = append(row, fmt.Sprintf("%.1f", score))
+			} else {
+				row = append(row, "N/A")
+			}
+		}
+		if site.Error != "" {
+			row[0] += " (incomplete)"
+		}
+		rows = append(rows, row)
+	}
+	ui.Table([]string{"Site", "Pages", "Failed", "Performance", "Accessibility", "Best Practices", "SEO"}, rows)
+}
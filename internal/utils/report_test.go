@@ -16,13 +16,13 @@ import (
 )
 
 // Mock server.GenerateHTMLFile
-var mockGenerateHTMLFile func(results []*types.PageResult, filename string) error
+var mockGenerateHTMLFile func(report *types.ReportData, filename string) error
 
 func init() {
 	// Replace the actual function with a mock for testing
-	serverGenerateHTMLFile = func(results []*types.PageResult, filename string) error {
+	serverGenerateHTMLFile = func(report *types.ReportData, filename string) error {
 		if mockGenerateHTMLFile != nil {
-			return mockGenerateHTMLFile(results, filename)
+			return mockGenerateHTMLFile(report, filename)
 		}
 		return fmt.Errorf("mock not set")
 	}
@@ -33,19 +33,24 @@ func TestSaveJSONReport(t *testing.T) {
 		{URL: "http://example.com/page1"},
 		{URL: "http://example.com/page2"},
 	}
+	report := &types.ReportData{
+		Results: results,
+		Filter:  &types.FilterStats{Total: 3, Kept: 2, Excluded: 1, Rules: []types.FilterRuleStat{{Rule: "/tag/**", Excluded: 1}}},
+	}
 	filename := "test_report.json"
 	defer os.Remove(filename)
 
-	err := SaveJSONReport(results, filename)
+	err := SaveJSONReport(report, filename)
 	assert.NoError(t, err)
 
 	content, err := os.ReadFile(filename)
 	assert.NoError(t, err)
 
-	var decodedResults []*types.PageResult
-	err = json.Unmarshal(content, &decodedResults)
+	var decoded types.ReportData
+	err = json.Unmarshal(content, &decoded)
 	assert.NoError(t, err)
-	assert.Equal(t, results, decodedResults)
+	assert.Equal(t, results, decoded.Results)
+	assert.Equal(t, report.Filter, decoded.Filter)
 }
 
 func TestSaveJSONToStdout(t *testing.T) {
@@ -57,39 +62,40 @@ func TestSaveJSONToStdout(t *testing.T) {
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 
-	err := SaveJSONToStdout(results)
+	err := SaveJSONToStdout(&types.ReportData{Results: results})
 	assert.NoError(t, err)
 
 	w.Close()
 	out, _ := io.ReadAll(r)
 	os.Stdout = oldStdout
 
-	var decodedResults []*types.PageResult
-	err = json.Unmarshal(out, &decodedResults)
+	var decoded types.ReportData
+	err = json.Unmarshal(out, &decoded)
 	assert.NoError(t, err)
-	assert.Equal(t, results, decodedResults)
+	assert.Equal(t, results, decoded.Results)
+	assert.NotContains(t, string(out), `"filter"`, "filter stats should be omitted when no rules were applied")
 }
 
 func TestSaveHTMLReport(t *testing.T) {
-	results := []*types.PageResult{{URL: "http://example.com/html"}}
+	report := &types.ReportData{Results: []*types.PageResult{{URL: "http://example.com/html"}}}
 	filename := "test_report.html"
 
 	// Set the mock to return no error
-	mockGenerateHTMLFile = func(r []*types.PageResult, f string) error {
-		assert.Equal(t, results, r)
+	mockGenerateHTMLFile = func(r *types.ReportData, f string) error {
+		assert.Equal(t, report, r)
 		assert.Equal(t, filename, f)
 		return nil
 	}
 
-	err := SaveHTMLReport(results, filename)
+	err := SaveHTMLReport(report, filename)
 	assert.NoError(t, err)
 
 	// Set the mock to return an error
-	mockGenerateHTMLFile = func(r []*types.PageResult, f string) error {
+	mockGenerateHTMLFile = func(r *types.ReportData, f string) error {
 		return fmt.Errorf("mock HTML generation error")
 	}
 
-	err = SaveHTMLReport(results, filename)
+	err = SaveHTMLReport(report, filename)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to generate HTML report")
 }
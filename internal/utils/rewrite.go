@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/mattjh1/psi-map/internal/types"
+)
+
+// ParseRewritePairs parses "from=to" flag values
+func ParseRewritePairs(values []string, flag string) ([]types.RewritePair, error) {
+	pairs := make([]types.RewritePair, 0, len(values))
+	for _, value := range values {
+		from, to, ok := strings.Cut(value, "=")
+		if !ok || strings.TrimSpace(from) == "" {
+			return nil, fmt.Errorf("invalid --%s value %q (expected from=to)", flag, value)
+		}
+		pairs = append(pairs, types.RewritePair{From: strings.TrimSpace(from), To: strings.TrimSpace(to)})
+	}
+	return pairs, nil
+}
+
+// URLRewriter maps sitemap URLs to the URLs that are actually analyzed
+type URLRewriter struct {
+	hosts  map[string]string
+	paths  []types.RewritePair
+	params []types.RewritePair
+}
+
+// NewURLRewriter validates the rewrite rules
+func NewURLRewriter(cfg *types.RewriteConfig) (*URLRewriter, error) {
+	r := &URLRewriter{hosts: make(map[string]string)}
+	if cfg == nil {
+		return r, nil
+	}
+
+	for _, h := range cfg.Hosts {
+		if h.To == "" || strings.Contains(h.From, "/") || strings.Contains(h.To, "/") {
+			return nil, fmt.Errorf("invalid host rewrite %s=%s (expected old.host=new.host)", h.From, h.To)
+		}
+		r.hosts[strings.ToLower(h.From)] = h.To
+	}
+	for _, p := range cfg.Paths {
+		if !strings.HasPrefix(p.From, "/") || !strings.HasPrefix(p.To, "/") {
+			return nil, fmt.Errorf("invalid path rewrite %s=%s (both prefixes must start with /)", p.From, p.To)
+		}
+		r.paths = append(r.paths, p)
+	}
+	// Longest prefix first so /blog/archive wins over /blog
+	sort.SliceStable(r.paths, func(i, j int) bool {
+		return len(r.paths[i].From) > len(r.paths[j].From)
+	})
+	r.params = cfg.Params
+	return r, nil
+}
+
+// IsEmpty reports whether no rewrite rules were given
+func (r *URLRewriter) IsEmpty() bool {
+	return len(r.hosts) == 0 && len(r.paths) == 0 && len(r.params) == 0
+}
+
+// Rewrite applies the host, path prefix and query parameter rules to raw.
+// URLs that cannot be parsed are returned unchanged.
+func (r *URLRewriter) Rewrite(raw string) string {
+	if r.IsEmpty() {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return raw
+	}
+
+	if to, ok := r.hosts[strings.ToLower(u.Host)]; ok {
+		u.Host = to
+	}
+
+	for _, p := range r.paths {
+		if rest, ok := cutPathPrefix(u.Path, p.From); ok {
+			u.Path = strings.TrimSuffix(p.To, "/") + rest
+			if u.Path == "" {
+				u.Path = "/"
+			}
+			u.RawPath = ""
+			break
+		}
+	}
+
+	if len(r.params) > 0 {
+		keys := make([]string, len(r.params))
+		for i, p := range r.params {
+			keys[i] = p.From
+		}
+		query := dropQueryParams(u.RawQuery, keys)
+		for _, p := range r.params {
+			if query != "" {
+				query += "&"
+			}
+			query += url.QueryEscape(p.From) + "=" + url.QueryEscape(p.To)
+		}
+		u.RawQuery = query
+	}
+	return u.String()
+}
+
+// cutPathPrefix removes prefix from p when it matches whole path segments,
+// so /en matches /en and /en/about but not /english
+func cutPathPrefix(p, prefix string) (string, bool) {
+	trimmed := strings.TrimSuffix(prefix, "/")
+	if p == trimmed {
+		return "", true
+	}
+	if rest, ok := strings.CutPrefix(p, trimmed+"/"); ok {
+		return "/" + rest, true
+	}
+	return "", false
+}
@@ -0,0 +1,81 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLRewriter_Rewrite(t *testing.T) {
+	rewriter, err := NewURLRewriter(&types.RewriteConfig{
+		Hosts: []types.RewritePair{{From: "www.example.com", To: "staging.example.com"}},
+		Paths: []types.RewritePair{
+			{From: "/blog", To: "/news"},
+			{From: "/blog/archive", To: "/old"},
+		},
+		Params: []types.RewritePair{{From: "preview", To: "1"}},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{"host and param", "https://www.example.com/about", "https://staging.example.com/about?preview=1"},
+		{"host is case insensitive", "https://WWW.example.com/", "https://staging.example.com/?preview=1"},
+		{"other host untouched", "https://cdn.example.com/a", "https://cdn.example.com/a?preview=1"},
+		{"path prefix", "https://www.example.com/blog/post", "https://staging.example.com/news/post?preview=1"},
+		{"exact path prefix", "https://www.example.com/blog", "https://staging.example.com/news?preview=1"},
+		{"longest prefix wins", "https://www.example.com/blog/archive/2020", "https://staging.example.com/old/2020?preview=1"},
+		{"prefix matches whole segments", "https://www.example.com/blogger", "https://staging.example.com/blogger?preview=1"},
+		{"existing param replaced", "https://www.example.com/a?page=2&preview=0", "https://staging.example.com/a?page=2&preview=1"},
+		{"not a URL", "not a url", "not a url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, rewriter.Rewrite(tt.input))
+		})
+	}
+}
+
+func TestURLRewriter_Empty(t *testing.T) {
+	rewriter, err := NewURLRewriter(nil)
+	require.NoError(t, err)
+	assert.True(t, rewriter.IsEmpty())
+	assert.Equal(t, "https://example.com/a/", rewriter.Rewrite("https://example.com/a/"))
+}
+
+func TestNewURLRewriter_Invalid(t *testing.T) {
+	_, err := NewURLRewriter(&types.RewriteConfig{
+		Hosts: []types.RewritePair{{From: "https://www.example.com", To: "staging.example.com"}},
+	})
+	require.Error(t, err)
+
+	_, err = NewURLRewriter(&types.RewriteConfig{
+		Paths: []types.RewritePair{{From: "blog", To: "/news"}},
+	})
+	require.Error(t, err)
+}
+
+func TestParseRewritePairs(t *testing.T) {
+	pairs, err := ParseRewritePairs([]string{"www.example.com=staging.example.com", "flag="}, "rewrite-host")
+	require.NoError(t, err)
+	assert.Equal(t, []types.RewritePair{
+		{From: "www.example.com", To: "staging.example.com"},
+		{From: "flag", To: ""},
+	}, pairs)
+
+	_, err = ParseRewritePairs([]string{"missing-separator"}, "rewrite-host")
+	require.ErrorContains(t, err, "--rewrite-host")
+}
+
+func TestResultsByURL_OriginalURL(t *testing.T) {
+	result := &types.PageResult{URL: "https://staging.example.com/a", OriginalURL: "https://www.example.com/a"}
+	byURL := resultsByURL([]*types.PageResult{result, nil})
+	assert.Same(t, result, byURL["https://staging.example.com/a"])
+	assert.Same(t, result, byURL["https://www.example.com/a"])
+}
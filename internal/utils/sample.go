@@ -0,0 +1,299 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattjh1/psi-map/internal/constants"
+	"github.com/mattjh1/psi-map/internal/logger"
+	"github.com/mattjh1/psi-map/internal/server"
+	"github.com/mattjh1/psi-map/internal/types"
+)
+
+// idSegment matches path segments that are clearly identifiers rather than names
+var idSegment = regexp.MustCompile(`(?i)^(\d+|[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}|[0-9a-f]{16,})$`)
+
+// entryCluster groups sitemap entries that share one path pattern
+type entryCluster struct {
+	Pattern string
+	Entries []types.URL
+}
+
+// clusterMember is a sitemap entry split into its normalized path segments
+type clusterMember struct {
+	Entry    types.URL
+	Host     string
+	Segments []string
+}
+
+// clusterEntries groups entries by path pattern. Segments that look like IDs
+// become :id, and any path position with more distinct values than
+// constants.ClusterVariantThreshold under the same prefix becomes :slug.
+// Clusters are returned largest first.
+func clusterEntries(entries []types.URL) []*entryCluster {
+	hosts := make(map[string]bool)
+	groups := make(map[string][]*clusterMember)
+	groupOrder := make([]string, 0)
+
+	for _, entry := range entries {
+		member := &clusterMember{Entry: entry}
+		if u, err := url.Parse(entry.Loc); err == nil {
+			member.Host = strings.ToLower(u.Host)
+			for _, seg := range strings.Split(strings.Trim(u.Path, "/"), "/") {
+				if seg == "" {
+					continue
+				}
+				if idSegment.MatchString(seg) {
+					seg = ":id"
+				}
+				member.Segments = append(member.Segments, seg)
+			}
+		}
+		hosts[member.Host] = true
+
+		// Patterns never span hosts or path depths
+		key := member.Host + "|" + strconv.Itoa(len(member.Segments))
+		if _, ok := groups[key]; !ok {
+			groupOrder = append(groupOrder, key)
+		}
+		groups[key] = append(groups[key], member)
+	}
+
+	byPattern := make(map[string]*entryCluster)
+	clusters := make([]*entryCluster, 0)
+	emit := func(members []*clusterMember, pattern []string) {
+		name := "/" + strings.Join(pattern, "/")
+		if len(hosts) > 1 {
+			name = members[0].Host + name
+		}
+		cluster, ok := byPattern[name]
+		if !ok {
+			cluster = &entryCluster{Pattern: name}
+			byPattern[name] = cluster
+			clusters = append(clusters, cluster)
+		}
+		for _, m := range members {
+			cluster.Entries = append(cluster.Entries, m.Entry)
+		}
+	}
+
+	for _, key := range groupOrder {
+		assignPattern(groups[key], 0, nil, emit)
+	}
+
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return len(clusters[i].Entries) > len(clusters[j].Entries)
+	})
+	return clusters
+}
+
+// assignPattern walks the path positions left to right, splitting members on
+// literal segments and collapsing high-cardinality positions into :slug
+func assignPattern(members []*clusterMember, pos int, prefix []string, emit func([]*clusterMember, []string)) {
+	if pos >= len(members[0].Segments) {
+		emit(members, prefix)
+		return
+	}
+
+	values := make([]string, 0)
+	byValue := make(map[string][]*clusterMember)
+	for _, m := range members {
+		seg := m.Segments[pos]
+		if _, ok := byValue[seg]; !ok {
+			values = append(values, seg)
+		}
+		byValue[seg] = append(byValue[seg], m)
+	}
+
+	if len(values) > constants.ClusterVariantThreshold {
+		assignPattern(members, pos+1, appendSegment(prefix, ":slug"), emit)
+		return
+	}
+	for _, value := range values {
+		assignPattern(byValue[value], pos+1, appendSegment(prefix, value), emit)
+	}
+}
+
+// appendSegment returns a new slice so sibling branches never share storage
+func appendSegment(prefix []string, seg string) []string {
+	next := make([]string, len(prefix), len(prefix)+1)
+	copy(next, prefix)
+	return append(next, seg)
+}
+
+// SampleURLs clusters the entries by path pattern and picks up to
+// cfg.PerCluster representative URLs from each cluster. The sampled URLs keep
+// their sitemap order.
+func SampleURLs(entries []types.URL, cfg *types.SampleConfig) ([]string, *types.SampleReport, error) {
+	if err := ValidateSampleConfig(cfg); err != nil {
+		return nil, nil, err
+	}
+
+	mode := cfg.Mode
+	if mode == "" {
+		mode = types.SampleRandom
+	}
+	var order func([]types.URL)
+	switch mode {
+	case types.SampleRandom:
+		rng := rand.New(rand.NewPCG(uint64(cfg.Seed), 0)) // #nosec G115,G404 - sampling does not need crypto randomness
+		order = func(e []types.URL) {
+			rng.Shuffle(len(e), func(i, j int) { e[i], e[j] = e[j], e[i] })
+		}
+	case types.SamplePriority:
+		order = func(e []types.URL) {
+			sort.SliceStable(e, func(i, j int) bool { return entryPriority(e[i]) > entryPriority(e[j]) })
+		}
+	case types.SampleLastmod:
+		order = func(e []types.URL) {
+			sort.SliceStable(e, func(i, j int) bool { return entryLastmod(e[i]).After(entryLastmod(e[j])) })
+		}
+	}
+
+	report := &types.SampleReport{
+		Mode:       mode,
+		PerCluster: cfg.PerCluster,
+		TotalURLs:  len(entries),
+	}
+
+	selected := make(map[string]bool)
+	for _, cluster := range clusterEntries(entries) {
+		candidates := make([]types.URL, len(cluster.Entries))
+		copy(candidates, cluster.Entries)
+		order(candidates)
+
+		picked := make([]string, 0, cfg.PerCluster)
+		for _, entry := range candidates[:min(cfg.PerCluster, len(candidates))] {
+			picked = append(picked, entry.Loc)
+			selected[entry.Loc] = true
+		}
+		report.Clusters = append(report.Clusters, types.URLCluster{
+			Pattern: cluster.Pattern,
+			Size:    len(cluster.Entries),
+			Sampled: picked,
+		})
+	}
+
+	urls := make([]string, 0, len(selected))
+	for _, entry := range entries {
+		if selected[entry.Loc] {
+			urls = append(urls, entry.Loc)
+			delete(selected, entry.Loc)
+		}
+	}
+	report.SampledURLs = len(urls)
+	return urls, report, nil
+}
+
+// ValidateSampleConfig checks the sample size and mode
+func ValidateSampleConfig(cfg *types.SampleConfig) error {
+	if cfg.PerCluster < 1 {
+		return fmt.Errorf("sample size must be at least 1, got %d", cfg.PerCluster)
+	}
+	switch cfg.Mode {
+	case "", types.SampleRandom, types.SamplePriority, types.SampleLastmod:
+		return nil
+	default:
+		return fmt.Errorf("unsupported sample mode: %s (supported: random, priority, lastmod)", cfg.Mode)
+	}
+}
+
+// entryPriority returns the sitemap priority, defaulting to the protocol's 0.5
+func entryPriority(entry types.URL) float64 {
+	if p, err := strconv.ParseFloat(entry.Priority, 64); err == nil {
+		return p
+	}
+	return constants.DefaultSamplePriority
+}
+
+// entryLastmod parses the W3C datetime lastmod, returning the zero time when absent
+func entryLastmod(entry types.URL) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04Z07:00", time.DateOnly} {
+		if t, err := time.Parse(layout, entry.Lastmod); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// ExtrapolateSample fills in each cluster's stats from its sampled results and
+// estimates site-wide scores by weighting each cluster by its size
+func ExtrapolateSample(report *types.SampleReport, results []*types.PageResult) {
+	byURL := resultsByURL(results)
+
+	weighted := make(map[string]float64)
+	weights := make(map[string]int)
+
+	for i := range report.Clusters {
+		cluster := &report.Clusters[i]
+		clusterResults := make([]*types.PageResult, 0, len(cluster.Sampled))
+		for _, u := range cluster.Sampled {
+			if result, ok := byURL[u]; ok {
+				clusterResults = append(clusterResults, result)
+			}
+		}
+
+		summary := server.GenerateSummary(clusterResults)
+		cluster.Analyzed = summary.SuccessfulPages
+		if cluster.Analyzed == 0 {
+			continue
+		}
+
+		cluster.AverageScores = summary.AverageScores
+		cluster.EstimatedDistribution = make(map[string][]int, len(summary.ScoreDistribution))
+		for category, dist := range summary.ScoreDistribution {
+			cluster.EstimatedDistribution[category] = scaleDistribution(dist, cluster.Size)
+		}
+		for category, avg := range summary.AverageScores {
+			weighted[category] += avg * float64(cluster.Size)
+			weights[category] += cluster.Size
+		}
+	}
+
+	report.EstimatedScores = make(map[string]float64, len(weighted))
+	for category, total := range weighted {
+		report.EstimatedScores[category] = total / float64(weights[category])
+	}
+}
+
+// scaleDistribution scales sampled bucket counts up to the cluster size
+func scaleDistribution(dist []int, size int) []int {
+	total := 0
+	for _, n := range dist {
+		total += n
+	}
+	scaled := make([]int, len(dist))
+	if total == 0 {
+		return scaled
+	}
+	for i, n := range dist {
+		scaled[i] = int(math.Round(float64(n) * float64(size) / float64(total)))
+	}
+	return scaled
+}
+
+// PrintSampleReport logs the clusters found and their extrapolated performance
+func PrintSampleReport(report *types.SampleReport) {
+	log := logger.GetLogger()
+	log.Tagged("SAMPLE", "Analyzed %d of %d URL(s) across %d cluster(s) (%s, %d per cluster)", "🧪",
+		report.SampledURLs, report.TotalURLs, len(report.Clusters), report.Mode, report.PerCluster)
+	for i := range report.Clusters {
+		cluster := &report.Clusters[i]
+		perf := "n/a"
+		if score, ok := cluster.AverageScores["performance"]; ok {
+			perf = fmt.Sprintf("%.1f", score)
+		}
+		log.Tagged("SAMPLE", "  %s: %d URL(s), %d sampled, est. performance %s", "",
+			cluster.Pattern, cluster.Size, len(cluster.Sampled), perf)
+	}
+	if score, ok := report.EstimatedScores["performance"]; ok {
+		log.Tagged("SAMPLE", "Estimated site-wide performance: %.1f", "", score)
+	}
+}
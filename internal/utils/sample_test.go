@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func productEntries(n int) []types.URL {
+	entries := make([]types.URL, 0, n)
+	for i := range n {
+		entries = append(entries, types.URL{
+			Loc:      fmt.Sprintf("https://shop.example.com/products/item-%d", i),
+			Lastmod:  fmt.Sprintf("2024-01-%02d", i+1),
+			Priority: fmt.Sprintf("0.%d", i%10),
+		})
+	}
+	return entries
+}
+
+func clusterPatterns(clusters []*entryCluster) map[string]int {
+	patterns := make(map[string]int, len(clusters))
+	for _, c := range clusters {
+		patterns[c.Pattern] = len(c.Entries)
+	}
+	return patterns
+}
+
+func TestClusterEntries(t *testing.T) {
+	entries := productEntries(20)
+	entries = append(entries,
+		types.URL{Loc: "https://shop.example.com/"},
+		types.URL{Loc: "https://shop.example.com/about"},
+		types.URL{Loc: "https://shop.example.com/contact"},
+		types.URL{Loc: "https://shop.example.com/orders/123"},
+		types.URL{Loc: "https://shop.example.com/orders/456"},
+		types.URL{Loc: "https://shop.example.com/products/item-1/reviews"},
+	)
+
+	clusters := clusterEntries(entries)
+	patterns := clusterPatterns(clusters)
+
+	assert.Equal(t, 20, patterns["/products/:slug"])
+	assert.Equal(t, 2, patterns["/orders/:id"], "numeric segments should collapse to :id")
+	assert.Equal(t, 1, patterns["/about"], "low-cardinality segments should stay literal")
+	assert.Equal(t, 1, patterns["/contact"])
+	assert.Equal(t, 1, patterns["/"])
+	assert.Equal(t, 1, patterns["/products/item-1/reviews"], "different path depths should not share a cluster")
+	assert.Equal(t, "/products/:slug", clusters[0].Pattern, "largest cluster should come first")
+}
+
+func TestClusterEntries_MultipleHosts(t *testing.T) {
+	entries := []types.URL{
+		{Loc: "https://a.example.com/about"},
+		{Loc: "https://b.example.com/about"},
+	}
+	patterns := clusterPatterns(clusterEntries(entries))
+	assert.Equal(t, map[string]int{"a.example.com/about": 1, "b.example.com/about": 1}, patterns)
+}
+
+func TestSampleURLs_Random(t *testing.T) {
+	entries := append(productEntries(30), types.URL{Loc: "https://shop.example.com/"})
+
+	urls, report, err := SampleURLs(entries, &types.SampleConfig{PerCluster: 3, Mode: types.SampleRandom, Seed: 7})
+	require.NoError(t, err)
+	assert.Len(t, urls, 4)
+	assert.Equal(t, 31, report.TotalURLs)
+	assert.Equal(t, 4, report.SampledURLs)
+	require.Len(t, report.Clusters, 2)
+	assert.Equal(t, 30, report.Clusters[0].Size)
+	assert.Len(t, report.Clusters[0].Sampled, 3)
+
+	again, _, err := SampleURLs(entries, &types.SampleConfig{PerCluster: 3, Mode: types.SampleRandom, Seed: 7})
+	require.NoError(t, err)
+	assert.Equal(t, urls, again, "the same seed should pick the same sample")
+}
+
+func TestSampleURLs_PriorityAndLastmod(t *testing.T) {
+	entries := productEntries(12)
+
+	_, report, err := SampleURLs(entries, &types.SampleConfig{PerCluster: 2, Mode: types.SamplePriority})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"https://shop.example.com/products/item-9",
+		"https://shop.example.com/products/item-8",
+	}, report.Clusters[0].Sampled)
+
+	urls, report, err := SampleURLs(entries, &types.SampleConfig{PerCluster: 2, Mode: types.SampleLastmod})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"https://shop.example.com/products/item-11",
+		"https://shop.example.com/products/item-10",
+	}, report.Clusters[0].Sampled)
+	assert.Equal(t, []string{
+		"https://shop.example.com/products/item-10",
+		"https://shop.example.com/products/item-11",
+	}, urls, "sampled URLs should keep sitemap order")
+}
+
+func TestSampleURLs_InvalidConfig(t *testing.T) {
+	_, _, err := SampleURLs(productEntries(3), &types.SampleConfig{PerCluster: 0})
+	assert.Error(t, err)
+
+	_, _, err = SampleURLs(productEntries(3), &types.SampleConfig{PerCluster: 1, Mode: "newest"})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported sample mode")
+}
+
+func TestExtrapolateSample(t *testing.T) {
+	report := &types.SampleReport{
+		Clusters: []types.URLCluster{
+			{Pattern: "/products/:slug", Size: 100, Sampled: []string{"https://x.com/products/a", "https://x.com/products/b"}},
+			{Pattern: "/", Size: 1, Sampled: []string{"https://x.com/"}},
+			{Pattern: "/missing", Size: 5, Sampled: []string{"https://x.com/missing"}},
+		},
+	}
+	results := []*types.PageResult{
+		{URL: "https://x.com/products/a", Mobile: &types.Result{Scores: &types.CategoryScores{Performance: 40}}},
+		{URL: "https://x.com/products/b", Mobile: &types.Result{Scores: &types.CategoryScores{Performance: 60}}},
+		{URL: "https://x.com/", Mobile: &types.Result{Scores: &types.CategoryScores{Performance: 100}}},
+	}
+
+	ExtrapolateSample(report, results)
+
+	products := report.Clusters[0]
+	assert.Equal(t, 2, products.Analyzed)
+	assert.InDelta(t, 50.0, products.AverageScores["performance"], 0.001)
+	assert.Equal(t, []int{0, 50, 50}, products.EstimatedDistribution["performance"])
+
+	assert.Equal(t, 0, report.Clusters[2].Analyzed)
+	assert.Nil(t, report.Clusters[2].AverageScores)
+
+	// (50 * 100 + 100 * 1) / 101
+	assert.InDelta(t, 50.495, report.EstimatedScores["performance"], 0.001)
+}
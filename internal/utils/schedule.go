@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule decides when the next run of a watch starts
+type Schedule interface {
+	// Next returns the first run time strictly after t
+	Next(t time.Time) time.Time
+}
+
+// ParseSchedule builds a schedule from a fixed interval (e.g. 6h) or a
+// standard five-field cron expression. Exactly one must be given.
+func ParseSchedule(every time.Duration, cron string) (Schedule, error) {
+	switch {
+	case every > 0 && cron != "":
+		return nil, fmt.Errorf("use either --every or --cron, not both")
+	case every > 0:
+		if every < time.Minute {
+			return nil, fmt.Errorf("--every must be at least 1m, got %s", every)
+		}
+		return intervalSchedule(every), nil
+	case cron != "":
+		return ParseCron(cron)
+	default:
+		return nil, fmt.Errorf("a schedule is required (--every or --cron)")
+	}
+}
+
+// intervalSchedule runs at a fixed interval after the previous start
+type intervalSchedule time.Duration
+
+func (s intervalSchedule) Next(t time.Time) time.Time {
+	return t.Add(time.Duration(s))
+}
+
+// cronField is the set of allowed values of one cron field
+type cronField map[int]bool
+
+// CronSchedule is a parsed "minute hour day-of-month month day-of-week"
+// expression, evaluated in local time
+type CronSchedule struct {
+	minute, hour, dom, month, dow cronField
+
+	// Like cron, when both day fields are restricted a day matching either runs
+	domAny, dowAny bool
+}
+
+// cronBounds are the value ranges of the five fields
+var cronBounds = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+
+// ParseCron parses a five-field cron expression. Fields accept *, numbers,
+// ranges (1-5), lists (1,15) and steps (*/15, 9-17/2). Sunday is 0 or 7.
+func ParseCron(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronBounds) {
+		return nil, fmt.Errorf("invalid cron expression %q (expected 5 fields: minute hour day month weekday)", expr)
+	}
+	parsed := make([]cronField, len(fields))
+	for i, field := range fields {
+		hi := cronBounds[i][1]
+		if i == 4 {
+			hi = 7 // allow 7 for Sunday
+		}
+		values, err := parseCronField(field, cronBounds[i][0], hi)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		parsed[i] = values
+	}
+	if parsed[4][7] {
+		parsed[4][0] = true
+	}
+	return &CronSchedule{
+		minute: parsed[0],
+		hour:   parsed[1],
+		dom:    parsed[2],
+		month:  parsed[3],
+		dow:    parsed[4],
+		domAny: fields[2] == "*",
+		dowAny: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField expands one comma-separated cron field
+func parseCronField(field string, lo, hi int) (cronField, error) {
+	values := make(cronField)
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			step = n
+		}
+
+		from, to := lo, hi
+		if rangePart != "*" {
+			start, end, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if from, err = strconv.Atoi(start); err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(end); err != nil {
+					return nil, fmt.Errorf("invalid range %q", part)
+				}
+			} else if hasStep {
+				to = hi
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return nil, fmt.Errorf("%q is outside %d-%d", part, lo, hi)
+		}
+		for v := from; v <= to; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
+
+// Next returns the first minute after t matching the expression, or the zero
+// time when none does within four years (e.g. 0 0 30 2 *)
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := next.AddDate(4, 0, 0)
+	for next.Before(limit) {
+		switch {
+		case !c.month[int(next.Month())]:
+			next = time.Date(next.Year(), next.Month()+1, 1, 0, 0, 0, 0, next.Location())
+		case !c.dayMatches(next):
+			next = time.Date(next.Year(), next.Month(), next.Day()+1, 0, 0, 0, 0, next.Location())
+		case !c.hour[next.Hour()]:
+			next = time.Date(next.Year(), next.Month(), next.Day(), next.Hour()+1, 0, 0, 0, next.Location())
+		case !c.minute[next.Minute()]:
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// dayMatches applies cron's day-of-month / day-of-week rule
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	domOK := c.dom[t.Day()]
+	dowOK := c.dow[int(t.Weekday())]
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dowOK
+	case c.dowAny:
+		return domOK
+	default:
+		return domOK || dowOK
+	}
+}
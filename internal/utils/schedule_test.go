@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSchedule(t *testing.T) {
+	_, err := ParseSchedule(0, "")
+	require.ErrorContains(t, err, "schedule is required")
+	_, err = ParseSchedule(time.Hour, "0 * * * *")
+	require.ErrorContains(t, err, "not both")
+	_, err = ParseSchedule(time.Second, "")
+	require.ErrorContains(t, err, "at least 1m")
+
+	schedule, err := ParseSchedule(6*time.Hour, "")
+	require.NoError(t, err)
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	assert.Equal(t, start.Add(6*time.Hour), schedule.Next(start))
+}
+
+func TestCronSchedule_Next(t *testing.T) {
+	at := func(s string) time.Time {
+		v, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		require.NoError(t, err)
+		return v
+	}
+	// 2026-01-01 is a Thursday
+	from := at("2026-01-01 10:07")
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"* * * * *", "2026-01-01 10:08"},
+		{"*/15 * * * *", "2026-01-01 10:15"},
+		{"0 */6 * * *", "2026-01-01 12:00"},
+		{"30 2 * * *", "2026-01-02 02:30"},
+		{"0 9-17/4 * * *", "2026-01-01 13:00"},
+		{"0 0 1 * *", "2026-02-01 00:00"},
+		{"0 8 * * 1-5", "2026-01-02 08:00"},
+		{"0 8 * * 0", "2026-01-04 08:00"},
+		{"0 8 * * 7", "2026-01-04 08:00"},
+		{"0 0 15 3 *", "2026-03-15 00:00"},
+		{"0 0 13 * 5", "2026-01-02 00:00"}, // day 13 or any Friday
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			require.NoError(t, err)
+			assert.Equal(t, at(tt.want), schedule.Next(from))
+		})
+	}
+}
+
+func TestParseCron_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := ParseCron(expr)
+		assert.Error(t, err, expr)
+	}
+
+	schedule, err := ParseCron("0 0 30 2 *")
+	require.NoError(t, err)
+	assert.True(t, schedule.Next(time.Now()).IsZero())
+}
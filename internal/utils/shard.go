@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
+	"github.com/mattjh1/psi-map/internal/types"
+)
+
+// ParseShard parses a "--shard i/n" value
+func ParseShard(value string) (*types.ShardConfig, error) {
+	index, count, ok := strings.Cut(value, "/")
+	i, errI := strconv.Atoi(strings.TrimSpace(index))
+	n, errN := strconv.Atoi(strings.TrimSpace(count))
+	if !ok || errI != nil || errN != nil || n < 1 || i < 1 || i > n {
+		return nil, fmt.Errorf("invalid --shard value %q (expected i/n with 1 <= i <= n)", value)
+	}
+	return &types.ShardConfig{Index: i, Count: n}, nil
+}
+
+// InShard reports whether the URL belongs to the shard. URLs are assigned by
+// a hash of the URL, so every job agrees on the split without sharing the
+// list, whatever order the input lists URLs in. A nil shard keeps every URL.
+func InShard(url string, shard *types.ShardConfig) bool {
+	if shard == nil || shard.Count <= 1 {
+		return true
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(url))
+	return int(h.Sum32()%uint32(shard.Count)) == shard.Index-1
+}
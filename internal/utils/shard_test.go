@@ -0,0 +1,41 @@
+package utils
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShard(t *testing.T) {
+	shard, err := ParseShard("3/8")
+	require.NoError(t, err)
+	assert.Equal(t, &types.ShardConfig{Index: 3, Count: 8}, shard)
+
+	for _, value := range []string{"", "3", "0/8", "9/8", "a/b", "1/0"} {
+		_, err := ParseShard(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestInShard_SplitsEveryURLOnce(t *testing.T) {
+	const count = 4
+	perShard := make([]int, count)
+	for i := range 400 {
+		url := fmt.Sprintf("https://example.com/page-%d", i)
+		owners := 0
+		for index := 1; index <= count; index++ {
+			if InShard(url, &types.ShardConfig{Index: index, Count: count}) {
+				owners++
+				perShard[index-1]++
+			}
+		}
+		assert.Equal(t, 1, owners, url)
+	}
+	for _, n := range perShard {
+		assert.Greater(t, n, 50)
+	}
+	assert.True(t, InShard("https://example.com/", nil))
+}
@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattjh1/psi-map/internal/types"
+)
+
+// ParseSiteManifest reads a manifest listing one site per line as either
+// "<input>" or "<name> <input>". Blank lines and lines starting with # are
+// ignored.
+func ParseSiteManifest(path string) ([]types.Site, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open manifest: %w", err)
+	}
+	defer file.Close()
+
+	var sites []types.Site
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		switch len(fields) {
+		case 1:
+			sites = append(sites, types.Site{Sitemap: fields[0]})
+		case 2:
+			sites = append(sites, types.Site{Name: fields[0], Sitemap: fields[1]})
+		default:
+			return nil, fmt.Errorf("manifest line %d: expected \"<input>\" or \"<name> <input>\"", line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	return sites, nil
+}
+
+// NameSites fills in missing site names from their input, using the host of
+// remote inputs and the file name of local ones, and makes names unique
+func NameSites(sites []types.Site) {
+	seen := make(map[string]int, len(sites))
+	for i := range sites {
+		name := sites[i].Name
+		if name == "" {
+			name = siteName(sites[i].Sitemap)
+		}
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name = fmt.Sprintf("%s (%d)", name, n)
+		}
+		sites[i].Name = name
+	}
+}
+
+// siteName derives a display name from a sitemap input
+func siteName(input string) string {
+	if isRemoteInput(input) {
+		if u, err := url.Parse(input); err == nil && u.Host != "" {
+			return strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+		}
+	}
+	base := filepath.Base(input)
+	return strings.TrimSuffix(base, filepath.Ext(base))
+}
@@ -0,0 +1,52 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSiteManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sites.txt")
+	content := `# weekly client check
+https://www.acme.example/sitemap.xml
+
+globex https://globex.example/sitemap_index.xml
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	sites, err := ParseSiteManifest(path)
+	require.NoError(t, err)
+	assert.Equal(t, []types.Site{
+		{Sitemap: "https://www.acme.example/sitemap.xml"},
+		{Name: "globex", Sitemap: "https://globex.example/sitemap_index.xml"},
+	}, sites)
+}
+
+func TestParseSiteManifest_InvalidLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sites.txt")
+	require.NoError(t, os.WriteFile(path, []byte("a b c\n"), 0o600))
+
+	_, err := ParseSiteManifest(path)
+	require.ErrorContains(t, err, "line 1")
+}
+
+func TestNameSites(t *testing.T) {
+	sites := []types.Site{
+		{Sitemap: "https://www.acme.example/sitemap.xml"},
+		{Sitemap: "https://acme.example/blog-sitemap.xml"},
+		{Sitemap: "/data/globex.xml"},
+		{Name: "Initech", Sitemap: "https://initech.example/sitemap.xml"},
+	}
+	NameSites(sites)
+
+	names := make([]string, len(sites))
+	for i, s := range sites {
+		names[i] = s.Name
+	}
+	assert.Equal(t, []string{"acme.example", "acme.example (2)", "globex", "Initech"}, names)
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattjh1/psi-map/internal/types"
+)
+
+// staticErrorPages are build outputs that are never served at their own URL
+var staticErrorPages = map[string]bool{
+	"404.html": true,
+	"500.html": true,
+}
+
+// StaticDirURLs walks a static site build directory and maps every HTML page
+// to its URL under cfg.BaseURL, in lexical file order
+func StaticDirURLs(cfg *types.StaticDirConfig) ([]string, error) {
+	base, err := url.Parse(cfg.BaseURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q (expected http(s)://host)", cfg.BaseURL)
+	}
+	info, err := os.Stat(cfg.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access directory: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", cfg.Dir)
+	}
+
+	var urls []string
+	err = filepath.WalkDir(cfg.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		// Skip hidden files and framework internals such as _next or _astro
+		if p != cfg.Dir && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || !strings.EqualFold(path.Ext(name), ".html") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(cfg.Dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if staticErrorPages[rel] {
+			return nil
+		}
+		urls = append(urls, staticPageURL(base, rel, cfg.CleanURLs))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %s: %w", cfg.Dir, err)
+	}
+	return urls, nil
+}
+
+// staticPageURL maps a slash-separated file path relative to the build root
+// to its URL: a/index.html becomes /a/ and a/b.html becomes /a/b.html (or
+// /a/b with clean URLs)
+func staticPageURL(base *url.URL, rel string, cleanURLs bool) string {
+	pagePath := "/" + rel
+	switch {
+	case path.Base(rel) == "index.html":
+		pagePath = strings.TrimSuffix(pagePath, "index.html")
+	case cleanURLs:
+		pagePath = strings.TrimSuffix(pagePath, path.Ext(pagePath))
+	}
+
+	u := *base
+	u.Path = strings.TrimSuffix(base.Path, "/") + pagePath
+	u.RawPath = ""
+	u.RawQuery = ""
+	u.Fragment = ""
+	return u.String()
+}
@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeStaticSite(t *testing.T, files ...string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o750))
+		require.NoError(t, os.WriteFile(p, []byte("<html></html>"), 0o600))
+	}
+	return dir
+}
+
+func TestStaticDirURLs(t *testing.T) {
+	dir := writeStaticSite(t,
+		"index.html",
+		"about/index.html",
+		"blog/post.html",
+		"404.html",
+		"_next/static/page.html",
+		".well-known/x.html",
+		"styles.css",
+	)
+
+	urls, err := StaticDirURLs(&types.StaticDirConfig{Dir: dir, BaseURL: "https://preview.example.com"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"https://preview.example.com/about/",
+		"https://preview.example.com/blog/post.html",
+		"https://preview.example.com/",
+	}, urls)
+}
+
+func TestStaticDirURLs_CleanURLsAndBasePath(t *testing.T) {
+	dir := writeStaticSite(t, "index.html", "blog/post.html")
+
+	urls, err := StaticDirURLs(&types.StaticDirConfig{
+		Dir:       dir,
+		BaseURL:   "https://example.com/preview/",
+		CleanURLs: true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"https://example.com/preview/blog/post",
+		"https://example.com/preview/",
+	}, urls)
+}
+
+func TestStaticDirURLs_Invalid(t *testing.T) {
+	dir := writeStaticSite(t, "index.html")
+
+	_, err := StaticDirURLs(&types.StaticDirConfig{Dir: dir, BaseURL: "preview.example.com"})
+	require.ErrorContains(t, err, "invalid base URL")
+
+	_, err = StaticDirURLs(&types.StaticDirConfig{Dir: filepath.Join(dir, "index.html"), BaseURL: "https://example.com"})
+	require.ErrorContains(t, err, "not a directory")
+
+	_, err = StaticDirURLs(&types.StaticDirConfig{Dir: filepath.Join(dir, "missing"), BaseURL: "https://example.com"})
+	require.Error(t, err)
+}
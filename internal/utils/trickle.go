@@ -0,0 +1,53 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/mattjh1/psi-map/internal/types"
+)
+
+// TrickleCandidate is a URL due for analysis in a trickle run
+type TrickleCandidate struct {
+	URL      string
+	Analyzed time.Time // zero when never analyzed
+	Change   float64   // performance score change at the last analysis
+}
+
+// ValidateTrickleConfig checks the budget and order of a trickle run
+func ValidateTrickleConfig(cfg *types.TrickleConfig) error {
+	if cfg.Budget < 1 {
+		return fmt.Errorf("trickle budget must be at least 1, got %d", cfg.Budget)
+	}
+	switch cfg.Order {
+	case "", types.TrickleOldest, types.TrickleVolatile:
+		return nil
+	default:
+		return fmt.Errorf("unsupported trickle order: %s (supported: oldest, volatile)", cfg.Order)
+	}
+}
+
+// PlanTrickle picks up to the budget of URLs from due. URLs never analyzed
+// come first in the order given, then cached ones, oldest first or, with the
+// volatile order, those whose score moved most at their last analysis.
+func PlanTrickle(due []TrickleCandidate, cfg *types.TrickleConfig) []string {
+	ordered := make([]TrickleCandidate, len(due))
+	copy(ordered, due)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i], ordered[j]
+		if a.Analyzed.IsZero() || b.Analyzed.IsZero() {
+			return a.Analyzed.IsZero() && !b.Analyzed.IsZero()
+		}
+		if cfg.Order == types.TrickleVolatile && a.Change != b.Change {
+			return a.Change > b.Change
+		}
+		return a.Analyzed.Before(b.Analyzed)
+	})
+
+	picked := make([]string, 0, min(cfg.Budget, len(ordered)))
+	for _, c := range ordered[:min(cfg.Budget, len(ordered))] {
+		picked = append(picked, c.URL)
+	}
+	return picked
+}
@@ -0,0 +1,47 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanTrickle_NewURLsFirstThenOldest(t *testing.T) {
+	now := time.Now()
+	due := []TrickleCandidate{
+		{URL: "https://example.com/recent", Analyzed: now.Add(-25 * time.Hour)},
+		{URL: "https://example.com/new-a"},
+		{URL: "https://example.com/old", Analyzed: now.Add(-72 * time.Hour)},
+		{URL: "https://example.com/new-b"},
+	}
+
+	picked := PlanTrickle(due, &types.TrickleConfig{Budget: 3, Order: types.TrickleOldest})
+	assert.Equal(t, []string{"https://example.com/new-a", "https://example.com/new-b", "https://example.com/old"}, picked)
+}
+
+func TestPlanTrickle_VolatileOrder(t *testing.T) {
+	now := time.Now()
+	due := []TrickleCandidate{
+		{URL: "https://example.com/old-stable", Analyzed: now.Add(-72 * time.Hour)},
+		{URL: "https://example.com/jumpy", Analyzed: now.Add(-30 * time.Hour), Change: 18},
+		{URL: "https://example.com/new"},
+	}
+
+	picked := PlanTrickle(due, &types.TrickleConfig{Budget: 2, Order: types.TrickleVolatile})
+	assert.Equal(t, []string{"https://example.com/new", "https://example.com/jumpy"}, picked)
+}
+
+func TestPlanTrickle_BudgetLargerThanDue(t *testing.T) {
+	due := []TrickleCandidate{{URL: "https://example.com/"}}
+	assert.Equal(t, []string{"https://example.com/"}, PlanTrickle(due, &types.TrickleConfig{Budget: 10}))
+	assert.Empty(t, PlanTrickle(nil, &types.TrickleConfig{Budget: 10}))
+}
+
+func TestValidateTrickleConfig(t *testing.T) {
+	assert.NoError(t, ValidateTrickleConfig(&types.TrickleConfig{Budget: 100}))
+	assert.NoError(t, ValidateTrickleConfig(&types.TrickleConfig{Budget: 1, Order: types.TrickleVolatile}))
+	assert.Error(t, ValidateTrickleConfig(&types.TrickleConfig{Budget: 0}))
+	assert.Error(t, ValidateTrickleConfig(&types.TrickleConfig{Budget: 5, Order: "random"}))
+}
@@ -172,10 +172,12 @@ func ValidateExtension(ext string) (string, error) {
 
 	// Whitelist allowed extensions
 	allowedExtensions := map[string]bool{
-		".json": true,
-		".html": true,
-		".xml":  true,
-		".txt":  true,
+		".json":  true,
+		".html":  true,
+		".xml":   true,
+		".txt":   true,
+		".jsonl": true, // event logs
+		".csv":   true,
 	}
 
 	lowerExt := strings.ToLower(ext)
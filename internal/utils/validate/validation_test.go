@@ -111,6 +111,7 @@ func TestValidateFileName(t *testing.T) {
 		{"valid simple", "test", "test", ""},
 		{"valid complex", "test-file_123.backup", "test-file_123.backup", ""},
 		{"strips path", "/path/to/file.txt", "", "path separators"},
+		{"json lines", ".jsonl", ".jsonl", ""},
 		{"empty", "", "", "cannot be empty"},
 		{"invalid chars", "test<file>", "", "invalid characters"},
 		{"spaces", "test file", "", "invalid characters"},
@@ -174,6 +175,7 @@ func TestValidateExtension(t *testing.T) {
 		{"json with dot", ".json", ".json", ""},
 		{"json without dot", "json", ".json", ""},
 		{"case insensitive", ".json", ".json", ""},
+		{"json lines", ".jsonl", ".jsonl", ""},
 		{"empty", "", "", "cannot be empty"},
 		{"not allowed", ".exe", "", "not allowed"},
 	}
@@ -0,0 +1,252 @@
+package psimap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/mattjh1/psi-map/internal/constants"
+	"github.com/mattjh1/psi-map/internal/utils"
+	"github.com/mattjh1/psi-map/runner"
+)
+
+// PSI strategies
+const (
+	StrategyMobile  = "mobile"
+	StrategyDesktop = "desktop"
+)
+
+// Cache backends
+const (
+	CacheBackendFile = utils.CacheBackendFile
+	CacheBackendBolt = utils.CacheBackendBolt
+)
+
+// Fetcher runs one PSI analysis of a URL with the given strategy. Replace it
+// to route requests through a proxy, add credentials or stub PSI in tests.
+type Fetcher = runner.Fetcher
+
+// FetchPSI is the default Fetcher, calling the PageSpeed Insights API
+func FetchPSI(ctx context.Context, url, strategy string) Result {
+	return utils.FetchScoreContext(ctx, url, strategy)
+}
+
+// Options configures an Analyzer. The zero value analyzes mobile and desktop
+// through the PSI API with NumCPU/2 workers, no retries and no cache.
+type Options struct {
+	// Workers is the number of URLs analyzed at once, or the most with
+	// Adaptive
+	Workers int
+
+	// Adaptive starts with few workers and adds more while PSI stays fast,
+	// halving them when it rate limits
+	Adaptive bool
+
+	// Retries is how many more times a strategy is requested after a rate
+	// limit, a server error or a timeout, with a growing pause of 5s per
+	// attempt. 0 never retries.
+	Retries int
+
+	// Strategies lists StrategyMobile and/or StrategyDesktop
+	Strategies []string
+
+	// Fetcher defaults to FetchPSI
+	Fetcher Fetcher
+
+	// Cache reuses results stored by earlier runs; nil disables it
+	Cache *CacheOptions
+
+	// Events receives progress events; may be nil, and may be shared by
+	// analyzers running at the same time
+	Events *EventBus
+
+	// Resume may return a partial result for a URL along with the
+	// strategies still to analyze, or nil to analyze every strategy. With
+	// Cache set, the cache resumes URLs instead.
+	Resume func(url string) (partial *PageResult, strategies []string)
+
+	// Progress draws the psi-map progress display and log lines on stderr
+	Progress bool
+}
+
+// CacheOptions selects the psi-map result cache, shared with the command
+type CacheOptions struct {
+	// Key is the sitemap path or URL whose URL list is kept up to date.
+	// Results themselves are shared by every key that lists the URL.
+	Key string
+
+	// TTLHours is how long results stay valid; 0 keeps them forever
+	TTLHours int
+
+	// Backend is CacheBackendFile (the default) or CacheBackendBolt
+	Backend string
+
+	// Source names where results come from, so they are never mixed with
+	// results of another source. It defaults to "psi" with the default
+	// Fetcher and "custom" with any other.
+	Source string
+
+	// FailureTTL is how long a failed strategy is reused before it is
+	// analyzed again, alone. It defaults to an hour; a negative value
+	// analyzes failures again on every run.
+	FailureTTL time.Duration
+}
+
+// Analyzer runs PSI analyses with fixed options. It is safe for concurrent use.
+type Analyzer struct {
+	opts   Options
+	runner *runner.Runner
+}
+
+// NewAnalyzer validates the options
+func NewAnalyzer(opts Options) (*Analyzer, error) {
+	if opts.Workers <= 0 {
+		opts.Workers = max(1, runtime.NumCPU()/constants.CPUDivisor)
+	}
+	if len(opts.Strategies) == 0 {
+		opts.Strategies = []string{StrategyMobile, StrategyDesktop}
+	}
+	seen := make(map[string]bool, len(opts.Strategies))
+	for _, strategy := range opts.Strategies {
+		if strategy != StrategyMobile && strategy != StrategyDesktop {
+			return nil, fmt.Errorf("unknown strategy %q (expected %s or %s)", strategy, StrategyMobile, StrategyDesktop)
+		}
+		if seen[strategy] {
+			return nil, fmt.Errorf("strategy %q listed twice", strategy)
+		}
+		seen[strategy] = true
+	}
+	if opts.Retries < 0 {
+		return nil, fmt.Errorf("retries must not be negative, got %d", opts.Retries)
+	}
+	if opts.Cache != nil && opts.Cache.Key == "" {
+		return nil, fmt.Errorf("cache key is required (the sitemap path or URL)")
+	}
+	if opts.Cache != nil && opts.Cache.Backend != "" {
+		if err := utils.ValidateCacheBackend(opts.Cache.Backend); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Analyzer{
+		opts: opts,
+		runner: &runner.Runner{
+			Fetch:      opts.Fetcher,
+			Strategies: opts.Strategies,
+			Events:     opts.Events,
+			Quiet:      !opts.Progress,
+			Adaptive:   opts.Adaptive,
+			Retries:    opts.Retries,
+			Resume:     opts.Resume,
+		},
+	}, nil
+}
+
+// cacheProfile returns the settings results are cached under
+func (a *Analyzer) cacheProfile() utils.CacheProfile {
+	source := a.opts.Cache.Source
+	if source == "" {
+		source = utils.CacheSourcePSI
+		if a.opts.Fetcher != nil {
+			source = "custom"
+		}
+	}
+	return utils.CacheProfile{
+		Strategies: a.opts.Strategies,
+		Categories: utils.PSICategories,
+		Source:     source,
+	}
+}
+
+// Analyze analyzes the URLs and returns one result per URL, cached results
+// first. When ctx is cancelled, it returns the results finished so far along
+// with ctx.Err().
+func (a *Analyzer) Analyze(ctx context.Context, urls []string) ([]*PageResult, error) {
+	in := make(chan string)
+	go func() {
+		defer close(in)
+		for _, url := range urls {
+			select {
+			case in <- url:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return a.AnalyzeStream(ctx, in)
+}
+
+// AnalyzeStream analyzes URLs as they arrive until the channel is closed, so
+// a sitemap can be analyzed while it is still being read. Cached results are
+// returned without a request and new results are cached as they finish.
+func (a *Analyzer) AnalyzeStream(ctx context.Context, urls <-chan string) ([]*PageResult, error) {
+	if a.opts.Cache == nil {
+		results := a.runner.Stream(ctx, urls, a.opts.Workers)
+		return results, ctx.Err()
+	}
+
+	store, err := utils.OpenCacheStore(a.opts.Cache.Backend)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache: %w", err)
+	}
+	cache, err := utils.NewURLCache(store, a.cacheProfile(), a.opts.Cache.Key, nil, a.opts.Cache.TTLHours)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache: %w", err)
+	}
+	switch ttl := a.opts.Cache.FailureTTL; {
+	case ttl < 0:
+		cache.FailureTTL = 0
+	case ttl > 0:
+		cache.FailureTTL = ttl
+	}
+	events := a.opts.Events
+
+	var cached []*PageResult
+	toRun := make(chan string)
+	go func() {
+		defer close(toRun)
+		for url := range urls {
+			if result, ok := cache.Lookup(url); ok {
+				cached = append(cached, result)
+				events.Emit(Event{Type: EventCacheHit, URL: url, Result: result})
+				continue
+			}
+			select {
+			case toRun <- url:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	// Results are saved from this run's own result path, not from events,
+	// so calls sharing an event bus never save each other's results
+	var mu sync.Mutex
+	var saveErr error
+	r := *a.runner
+	r.Events = events
+	r.Resume = cache.Resume
+	r.Finished = func(result *PageResult) {
+		if err := cache.Save(result); err != nil {
+			mu.Lock()
+			saveErr = errors.Join(saveErr, err)
+			mu.Unlock()
+		}
+	}
+	fresh := r.Stream(ctx, toRun, a.opts.Workers)
+	results := make([]*PageResult, 0, len(cached)+len(fresh))
+	results = append(results, cached...)
+	results = append(results, fresh...)
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err := cache.Flush(); err != nil {
+		saveErr = errors.Join(saveErr, err)
+	}
+	if saveErr != nil {
+		return results, fmt.Errorf("failed to cache results: %w", saveErr)
+	}
+	return results, ctx.Err()
+}
@@ -0,0 +1,296 @@
+package psimap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mattjh1/psi-map/internal/logger"
+	"github.com/mattjh1/psi-map/internal/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubFetcher scores every page 80 and counts the requests it answers
+func stubFetcher(calls *int32) Fetcher {
+	return func(ctx context.Context, url, strategy string) Result {
+		atomic.AddInt32(calls, 1)
+		if url == "https://example.com/broken" {
+			return Result{URL: url, Strategy: strategy, Error: errors.New("API error: status 400")}
+		}
+		return Result{URL: url, Strategy: strategy, Scores: &CategoryScores{Performance: 80}}
+	}
+}
+
+func TestNewAnalyzer_RejectsUnknownStrategy(t *testing.T) {
+	_, err := NewAnalyzer(Options{Strategies: []string{"tablet"}})
+	require.ErrorContains(t, err, "unknown strategy")
+
+	_, err = NewAnalyzer(Options{Strategies: []string{StrategyMobile, StrategyMobile}})
+	require.ErrorContains(t, err, "listed twice")
+
+	_, err = NewAnalyzer(Options{Retries: -1})
+	require.ErrorContains(t, err, "retries must not be negative")
+
+	_, err = NewAnalyzer(Options{Cache: &CacheOptions{}})
+	require.ErrorContains(t, err, "cache key is required")
+
+	_, err = NewAnalyzer(Options{Cache: &CacheOptions{Key: "sitemap.xml", Backend: "sqlite"}})
+	require.ErrorContains(t, err, "unsupported cache backend")
+}
+
+func TestAnalyzer_Analyze(t *testing.T) {
+	var calls int32
+	events := NewEventBus()
+	var mu sync.Mutex
+	seen := make(map[EventType]int)
+	events.Subscribe(func(e Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[e.Type]++
+	})
+
+	analyzer, err := NewAnalyzer(Options{
+		Workers:    2,
+		Strategies: []string{StrategyMobile},
+		Fetcher:    stubFetcher(&calls),
+		Events:     events,
+	})
+	require.NoError(t, err)
+
+	urls := []string{"https://example.com/", "https://example.com/about", "https://example.com/broken"}
+	results, err := analyzer.Analyze(context.Background(), urls)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, int32(3), calls)
+	for _, result := range results {
+		assert.NotNil(t, result.Mobile)
+		assert.Nil(t, result.Desktop)
+	}
+
+	summary := Summarize(results)
+	assert.Equal(t, 2, summary.SuccessfulPages)
+	assert.Equal(t, 1, summary.FailedPages)
+	assert.Equal(t, 3, seen[EventQueued])
+	assert.Equal(t, 2, seen[EventCompleted])
+	assert.Equal(t, 1, seen[EventFailed])
+	assert.Equal(t, 1, seen[EventRunFinished])
+}
+
+func TestAnalyzer_RetriesOnlyWhenAsked(t *testing.T) {
+	var calls int32
+	analyzer, err := NewAnalyzer(Options{
+		Strategies: []string{StrategyMobile},
+		Fetcher: func(ctx context.Context, url, strategy string) Result {
+			atomic.AddInt32(&calls, 1)
+			return Result{URL: url, Strategy: strategy, Error: &utils.APIError{StatusCode: http.StatusTooManyRequests}}
+		},
+	})
+	require.NoError(t, err)
+
+	results, err := analyzer.Analyze(context.Background(), []string{"https://example.com/"})
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Error(t, results[0].Mobile.Error)
+	assert.Equal(t, int32(1), calls, "the zero value never retries")
+}
+
+func TestAnalyzer_CacheSkipsRequests(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	var calls int32
+	analyzer, err := NewAnalyzer(Options{
+		Fetcher: stubFetcher(&calls),
+		Cache:   &CacheOptions{Key: "https://example.com/sitemap.xml"},
+	})
+	require.NoError(t, err)
+
+	urls := []string{"https://example.com/", "https://example.com/about"}
+	_, err = analyzer.Analyze(context.Background(), urls)
+	require.NoError(t, err)
+	assert.Equal(t, int32(4), calls)
+
+	results, err := analyzer.Analyze(context.Background(), urls)
+	require.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, int32(4), calls, "second run is served from the cache")
+}
+
+func TestAnalyzer_ConcurrentCallsShareAnEventBus(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	var calls int32
+	events := NewEventBus()
+	var finished int32
+	events.Subscribe(func(e Event) {
+		if e.Type == EventCompleted || e.Type == EventFailed {
+			atomic.AddInt32(&finished, 1)
+		}
+	})
+	inputs := map[string][]string{
+		"https://a.example/sitemap.xml": {"https://a.example/", "https://a.example/about"},
+		"https://b.example/sitemap.xml": {"https://b.example/", "https://b.example/about"},
+	}
+
+	var wg sync.WaitGroup
+	for key, urls := range inputs {
+		analyzer, err := NewAnalyzer(Options{Fetcher: stubFetcher(&calls), Events: events, Cache: &CacheOptions{Key: key}})
+		require.NoError(t, err)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := analyzer.Analyze(context.Background(), urls)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.EqualValues(t, 4, atomic.LoadInt32(&finished), "the shared bus hears from both analyzers")
+
+	infos, err := utils.ListCacheFiles(24, false)
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+	for _, info := range infos {
+		assert.Equal(t, len(inputs[info.SitemapURL]), info.URLCount, "%s lists only its own URLs", info.SitemapURL)
+	}
+}
+
+func TestAnalyzer_CancelledContext(t *testing.T) {
+	var calls int32
+	analyzer, err := NewAnalyzer(Options{Fetcher: stubFetcher(&calls)})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results, err := analyzer.Analyze(ctx, []string{"https://example.com/"})
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, results)
+}
+
+func TestAnalyzer_AdaptiveConcurrencyGrows(t *testing.T) {
+	var calls int32
+	events := NewEventBus()
+	var mu sync.Mutex
+	var limits []int
+	events.Subscribe(func(e Event) {
+		if e.Type == EventConcurrency {
+			mu.Lock()
+			defer mu.Unlock()
+			limits = append(limits, e.Concurrency)
+		}
+	})
+
+	analyzer, err := NewAnalyzer(Options{
+		Workers:    4,
+		Adaptive:   true,
+		Strategies: []string{StrategyMobile},
+		Fetcher:    stubFetcher(&calls),
+		Events:     events,
+	})
+	require.NoError(t, err)
+
+	urls := make([]string, 0, 20)
+	for i := range 20 {
+		urls = append(urls, fmt.Sprintf("https://example.com/%d", i))
+	}
+	results, err := analyzer.Analyze(context.Background(), urls)
+	require.NoError(t, err)
+	assert.Len(t, results, 20)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotEmpty(t, limits)
+	assert.Equal(t, 4, limits[len(limits)-1], "healthy requests raise the limit up to Workers")
+}
+
+func TestAnalyzer_AdaptiveConcurrencyChangesAreLogged(t *testing.T) {
+	var buf bytes.Buffer
+	logger.Reset()
+	logger.Init(logger.WithOutput(&buf), logger.WithFormat(logger.FormatJSON))
+	t.Cleanup(logger.Reset)
+
+	var calls int32
+	events := NewEventBus()
+	var mu sync.Mutex
+	var changes []Event
+	events.Subscribe(func(e Event) {
+		if e.Type == EventConcurrency {
+			mu.Lock()
+			defer mu.Unlock()
+			changes = append(changes, e)
+		}
+	})
+
+	analyzer, err := NewAnalyzer(Options{
+		Workers:    3,
+		Adaptive:   true,
+		Progress:   true,
+		Strategies: []string{StrategyMobile},
+		Fetcher:    stubFetcher(&calls),
+		Events:     events,
+	})
+	require.NoError(t, err)
+	_, err = analyzer.Analyze(context.Background(), []string{"https://example.com/1", "https://example.com/2", "https://example.com/3"})
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotEmpty(t, changes)
+	assert.Equal(t, 2, changes[0].Previous)
+	assert.Equal(t, 3, changes[0].Concurrency)
+	var logged []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record struct{ Tag, Msg string }
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		if record.Tag == "ADAPTIVE" {
+			logged = append(logged, record.Msg)
+		}
+	}
+	require.Len(t, logged, 2, "the start and the change are logged")
+	assert.True(t, strings.HasPrefix(logged[1], "Concurrency 2 -> 3 (healthy at"), logged[1])
+}
+
+func TestAnalyzer_RefetchesOnlyFailedStrategy(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	var mu sync.Mutex
+	var fetched []string
+	desktopDown := true
+	fetcher := func(ctx context.Context, url, strategy string) Result {
+		mu.Lock()
+		defer mu.Unlock()
+		fetched = append(fetched, strategy)
+		if strategy == StrategyDesktop && desktopDown {
+			return Result{URL: url, Strategy: strategy, Error: errors.New("API error: status 400")}
+		}
+		return Result{URL: url, Strategy: strategy, Scores: &CategoryScores{Performance: 80}}
+	}
+	analyzer, err := NewAnalyzer(Options{
+		Fetcher: fetcher,
+		Cache:   &CacheOptions{Key: "https://example.com/sitemap.xml", FailureTTL: -1},
+	})
+	require.NoError(t, err)
+
+	urls := []string{"https://example.com/"}
+	_, err = analyzer.Analyze(context.Background(), urls)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{StrategyMobile, StrategyDesktop}, fetched)
+
+	fetched = nil
+	desktopDown = false
+	results, err := analyzer.Analyze(context.Background(), urls)
+	require.NoError(t, err)
+	assert.Equal(t, []string{StrategyDesktop}, fetched, "the cached mobile result is reused")
+	require.Len(t, results, 1)
+	require.NotNil(t, results[0].Mobile)
+	require.NotNil(t, results[0].Desktop)
+	assert.NoError(t, results[0].Desktop.Error)
+
+	fetched = nil
+	_, err = analyzer.Analyze(context.Background(), urls)
+	require.NoError(t, err)
+	assert.Empty(t, fetched, "both strategies are cached now")
+}
@@ -0,0 +1,82 @@
+// Package psimap analyzes web pages with Google PageSpeed Insights. It is the
+// library behind the psi-map command and can be embedded in other programs:
+//
+//	urls, err := psimap.LoadSitemap("https://example.com/sitemap.xml")
+//	if err != nil {
+//		return err
+//	}
+//	analyzer, err := psimap.NewAnalyzer(psimap.Options{Workers: 4})
+//	if err != nil {
+//		return err
+//	}
+//	results, err := analyzer.Analyze(ctx, urls)
+//	summary := psimap.Summarize(results)
+//
+// Requests use the PSI_API_KEY environment variable when it is set.
+package psimap
+
+import (
+	"github.com/mattjh1/psi-map/internal/server"
+	"github.com/mattjh1/psi-map/internal/types"
+	"github.com/mattjh1/psi-map/runner"
+)
+
+// Result types
+type (
+	// PageResult holds the results of every strategy analyzed for one page
+	PageResult = types.PageResult
+	// Result is the PSI result of one strategy
+	Result = types.Result
+	// CategoryScores are the Lighthouse category scores from 0 to 100
+	CategoryScores = types.CategoryScores
+	// Metrics are the Core Web Vitals and other lab metrics
+	Metrics = types.Metrics
+	// FieldData is real user data from the Chrome UX Report
+	FieldData = types.FieldData
+	// Opportunity is a suggested improvement with its potential savings
+	Opportunity = types.Opportunity
+	// Summary aggregates the results of many pages
+	Summary = types.ReportSummary
+	// Report is the document written by psi-map analyze
+	Report = types.ReportData
+	// SitemapURL is one <url> entry of a sitemap
+	SitemapURL = types.URL
+)
+
+// Event types
+type (
+	// Event reports the progress of a run
+	Event = types.Event
+	// EventType names a step in the life of a URL during a run
+	EventType = types.EventType
+	// EventBus fans events out to subscribers
+	EventBus = runner.EventBus
+)
+
+// Run events, see the types package for details
+const (
+	EventQueued           = types.EventQueued
+	EventCacheHit         = types.EventCacheHit
+	EventStarted          = types.EventStarted
+	EventStrategyFinished = types.EventStrategyFinished
+	EventRetry            = types.EventRetry
+	EventCompleted        = types.EventCompleted
+	EventFailed           = types.EventFailed
+	EventConcurrency      = types.EventConcurrency
+	EventRunFinished      = types.EventRunFinished
+)
+
+// NewEventBus returns a bus to pass in Options.Events
+func NewEventBus() *EventBus {
+	return runner.NewEventBus()
+}
+
+// Summarize aggregates scores, timings and failures over results
+func Summarize(results []*PageResult) Summary {
+	return server.GenerateSummary(results)
+}
+
+// NewReport wraps results in a report with a fresh summary
+func NewReport(results []*PageResult) *Report {
+	return server.NewReport(results)
+}
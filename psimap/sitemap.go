@@ -0,0 +1,21 @@
+package psimap
+
+import (
+	"context"
+
+	"github.com/mattjh1/psi-map/internal/utils"
+)
+
+// LoadSitemap returns the page URLs of a sitemap file or URL. Sitemap indexes
+// are followed into their child sitemaps.
+func LoadSitemap(input string) ([]string, error) {
+	return utils.ParseSitemap(input)
+}
+
+// StreamSitemap sends each entry of a sitemap file or URL as soon as it is
+// read. The entry channel is closed when parsing ends, after which the error
+// channel yields the parse error, if any. Cancel ctx to stop reading early:
+// the parser then closes the sitemap and both channels.
+func StreamSitemap(ctx context.Context, input string) (<-chan SitemapURL, <-chan error) {
+	return utils.StreamSitemapEntries(ctx, input)
+}
@@ -0,0 +1,38 @@
+package psimap
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamSitemap_StopsWhenCancelled(t *testing.T) {
+	var doc strings.Builder
+	doc.WriteString("<urlset>")
+	for i := range 1000 {
+		fmt.Fprintf(&doc, "<url><loc>https://example.com/%d</loc></url>", i)
+	}
+	doc.WriteString("</urlset>")
+	path := filepath.Join(t.TempDir(), "sitemap.xml")
+	require.NoError(t, os.WriteFile(path, []byte(doc.String()), 0o600))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	entries, errc := StreamSitemap(ctx, path)
+	first := <-entries
+	assert.Equal(t, "https://example.com/0", first.Loc)
+	cancel()
+
+	select {
+	case err := <-errc:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("StreamSitemap kept running after cancellation")
+	}
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"sync"
+	"time"
+
+	"github.com/mattjh1/psi-map/internal/types"
+)
+
+// EventBus fans run events out to subscribers. Subscribers are called on the
+// goroutine that emits the event, so they must return quickly. A nil bus
+// drops every event.
+type EventBus struct {
+	mu     sync.RWMutex
+	nextID int
+	subs   map[int]func(types.Event)
+}
+
+// NewEventBus returns a bus without subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{subs: make(map[int]func(types.Event))}
+}
+
+// Subscribe registers fn for every event emitted from now on and returns a
+// function that removes it
+func (b *EventBus) Subscribe(fn func(types.Event)) (unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = fn
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, id)
+	}
+}
+
+// Emit stamps the event with the current time when unset and hands it to
+// every subscriber
+func (b *EventBus) Emit(e types.Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, fn := range b.subs {
+		fn(e)
+	}
+}
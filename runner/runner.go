@@ -1,6 +1,8 @@
 package runner
 
 import (
+	"context"
+	"fmt"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -11,70 +13,299 @@ import (
 	"github.com/mattjh1/psi-map/internal/utils"
 )
 
-// RunBatch runs PSI tests concurrently for a list of URLs with limited concurrency.
-func RunBatch(urls []string, maxConcurrent int) []*types.PageResult {
-	// Get the singleton logger and configure it
-	log := logger.GetLogger()
+// Strategies are the PSI strategies analyzed by default
+var Strategies = []string{"mobile", "desktop"}
+
+// Fetcher runs one PSI analysis of a URL with the given strategy
+type Fetcher func(ctx context.Context, url, strategy string) types.Result
+
+// Runner analyzes URLs with a fixed set of strategies. The zero value calls
+// the PSI API for mobile and desktop, never retries and emits no events.
+type Runner struct {
+	Fetch      Fetcher   // defaults to the PSI API
+	Strategies []string  // "mobile" and/or "desktop", defaults to both
+	Events     *EventBus // may be nil
+
+	// Quiet turns off the progress display and log lines, for embedding
+	Quiet bool
+
+	// Adaptive starts with few URLs at once and moves up to the given
+	// concurrency as PSI latency and rate limits allow
+	Adaptive bool
+
+	// Retries is how many more times a strategy is requested after a rate
+	// limit, a server error or a timeout, pausing PSIRetryBackoff times the
+	// attempt number in between. 0 never retries.
+	Retries int
+
+	// Resume may return a partial result for a URL along with the
+	// strategies still to fetch, so only those are requested. It returns
+	// nil to fetch every strategy. May be nil.
+	Resume func(url string) (*types.PageResult, []string)
+
+	// Finished is called with each result of this runner as soon as it
+	// finishes, before its completed or failed event. May be nil.
+	Finished func(result *types.PageResult)
+}
 
-	// Create UI instance for rendering CLI elements
-	ui := log.UI()
+// Stream runs PSI tests for URLs as they arrive on the channel, with
+// limited concurrency, until the channel is closed. Results keep the order in
+// which the URLs arrived. Receiving blocks while all workers are busy, so a
+// slow PSI API holds back the producer instead of buffering URLs. When ctx is
+// cancelled, in-flight requests are stopped, URLs still arriving are
+// discarded and only finished results are returned. Completed and failed
+// events carry the result as soon as it finishes.
+func (r *Runner) Stream(ctx context.Context, urls <-chan string, maxConcurrent int) []*types.PageResult {
+	log := logger.GetLogger()
+	events := r.events()
 
 	var wg sync.WaitGroup
-	results := make([]*types.PageResult, len(urls))
-	sem := make(chan struct{}, maxConcurrent)
+	var mu sync.Mutex
+	results := make([]*types.PageResult, 0)
+	limit := r.newLimit(maxConcurrent)
 	var completed int32
 
-	// Run spinner for progress feedback
-	err := ui.RunProgressBar("Processing URLs", len(urls), func(increment func()) error {
-		for i, url := range urls {
+	err := r.track(func(increment func()) error {
+		defer r.showProgress(events, increment, &completed)()
+		for url := range urls {
+			if ctx.Err() == nil {
+				events.Emit(types.Event{Type: types.EventQueued, URL: url})
+			}
+			if !limit.Acquire(ctx) {
+				continue // drain so the producer can finish
+			}
+
+			mu.Lock()
+			i := len(results)
+			results = append(results, nil)
+			mu.Unlock()
+
 			wg.Add(1)
 			go func(i int, url string) {
 				defer wg.Done()
-				sem <- struct{}{}
-				defer func() { <-sem }()
-
-				start := time.Now()
-				var wgInner sync.WaitGroup
-				var mobile, desktop types.Result
-
-				wgInner.Add(constants.WaitGroupWorkers)
-				go func() {
-					defer wgInner.Done()
-					mobile = utils.FetchScore(url, "mobile")
-				}()
-
-				go func() {
-					defer wgInner.Done()
-					desktop = utils.FetchScore(url, "desktop")
-				}()
-
-				wgInner.Wait()
-				duration := time.Since(start)
-
-				results[i] = &types.PageResult{
-					URL:      url,
-					Mobile:   &mobile,
-					Desktop:  &desktop,
-					Duration: duration,
-				}
-
-				// Log progress with Tagged logging
-				log.Tagged("ANALYZE", "Processed URL: %s", "", url)
-				increment()
-				atomic.AddInt32(&completed, 1)
+				defer limit.Release()
+
+				result := r.analyzeURL(ctx, url, events, limit)
+				mu.Lock()
+				results[i] = result
+				mu.Unlock()
 			}(i, url)
 		}
 
 		wg.Wait()
-		return nil
+		return interrupted(ctx, int(atomic.LoadInt32(&completed)))
 	})
+	events.Emit(types.Event{Type: types.EventRunFinished})
+	results = finished(results)
+	if r.Quiet {
+		return results
+	}
 	if err != nil {
-		log.Error("Failed to process URLs: %v", err)
+		if ctx.Err() != nil {
+			log.Warn("Run interrupted, keeping %d finished result(s)", len(results))
+		} else {
+			log.Error("Failed to process URLs: %v", err)
+		}
 		return results
 	}
 
-	// Log success
 	log.Success("All tasks completed!")
-
 	return results
 }
+
+// newLimit returns the concurrency limit of one run, adaptive up to
+// maxConcurrent when enabled
+func (r *Runner) newLimit(maxConcurrent int) *utils.ConcurrencyLimit {
+	if !r.Adaptive {
+		return utils.NewFixedLimit(maxConcurrent)
+	}
+	limit := utils.NewAdaptiveLimit(constants.AdaptiveStartWorkers, maxConcurrent)
+	if !r.Quiet {
+		logger.GetLogger().Tagged("ADAPTIVE", "Adaptive concurrency: starting at %d, up to %d URL(s) at once", "🎚️", limit.Limit(), maxConcurrent)
+	}
+	return limit
+}
+
+// track runs task under a counting spinner, or no display at all when quiet
+func (r *Runner) track(task func(increment func()) error) error {
+	if r.Quiet {
+		return task(func() {})
+	}
+	return logger.GetLogger().UI().RunCounter("Processing URLs", task)
+}
+
+// showProgress drives the progress display from events: finished URLs are
+// logged and counted, retries and concurrency changes are logged. It returns
+// the unsubscribe func.
+func (r *Runner) showProgress(events *EventBus, increment func(), completed *int32) func() {
+	log := logger.GetLogger()
+	return events.Subscribe(func(e types.Event) {
+		switch e.Type {
+		case types.EventCompleted, types.EventFailed:
+			if !r.Quiet {
+				log.TaggedFields("ANALYZE", logger.Fields{URL: e.URL, Duration: time.Duration(e.DurationMS) * time.Millisecond},
+					"Processed URL: %s", "", e.URL)
+			}
+			increment()
+			atomic.AddInt32(completed, 1)
+		case types.EventRetry:
+			if !r.Quiet {
+				log.TaggedFields("PSI", logger.Fields{URL: e.URL}, "Retrying %s (%s), attempt %d: %s", "🔁", e.URL, e.Strategy, e.Attempt, e.Error)
+			}
+		case types.EventConcurrency:
+			if !r.Quiet {
+				log.Tagged("ADAPTIVE", "Concurrency %d -> %d (%s)", "🎚️", e.Previous, e.Concurrency, e.Reason)
+			}
+		}
+	})
+}
+
+// events returns a bus private to one run, so the progress display follows
+// only this run's URLs. Every event is passed on to r.Events, which other
+// runners may be reporting to as well.
+func (r *Runner) events() *EventBus {
+	bus := NewEventBus()
+	if r.Events != nil {
+		bus.Subscribe(r.Events.Emit)
+	}
+	return bus
+}
+
+// analyzeURL fetches every strategy for one URL in parallel, or only those
+// Resume leaves to fetch. It returns nil when ctx was cancelled before all
+// finished.
+func (r *Runner) analyzeURL(ctx context.Context, url string, events *EventBus, limit *utils.ConcurrencyLimit) *types.PageResult {
+	start := time.Now()
+	events.Emit(types.Event{Type: types.EventStarted, URL: url})
+
+	strategies := r.Strategies
+	if len(strategies) == 0 {
+		strategies = Strategies
+	}
+	result := &types.PageResult{URL: url}
+	if r.Resume != nil {
+		if partial, missing := r.Resume(url); partial != nil {
+			result, strategies = partial, missing
+		}
+	}
+	var wgInner sync.WaitGroup
+	fetched := make([]types.Result, len(strategies))
+	for i, strategy := range strategies {
+		wgInner.Add(1)
+		go func(i int, strategy string) {
+			defer wgInner.Done()
+			fetched[i] = r.fetchStrategy(ctx, url, strategy, events, limit)
+		}(i, strategy)
+	}
+
+	wgInner.Wait()
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	result.Duration = time.Since(start)
+	for i, strategy := range strategies {
+		switch strategy {
+		case "mobile":
+			result.Mobile = &fetched[i]
+		case "desktop":
+			result.Desktop = &fetched[i]
+		}
+	}
+	if r.Finished != nil {
+		r.Finished(result)
+	}
+	events.Emit(finishedEvent(result))
+	return result
+}
+
+// fetchStrategy fetches one strategy, retrying rate limits and transient
+// failures up to Retries times with a growing pause. Every response is
+// reported to the limit.
+func (r *Runner) fetchStrategy(ctx context.Context, url, strategy string, events *EventBus, limit *utils.ConcurrencyLimit) types.Result {
+	fetch := r.Fetch
+	if fetch == nil {
+		fetch = utils.FetchScoreContext
+	}
+	for attempt := 1; ; attempt++ {
+		result := fetch(ctx, url, strategy)
+		if ctx.Err() != nil {
+			return result
+		}
+		if previous, n, reason := limit.Observe(result.Elapsed, result.Error); reason != "" {
+			events.Emit(types.Event{Type: types.EventConcurrency, Concurrency: n, Previous: previous, Reason: reason})
+		}
+		if result.Error == nil || attempt > r.Retries || !utils.IsRetryable(result.Error) {
+			e := types.Event{
+				Type:       types.EventStrategyFinished,
+				URL:        url,
+				Strategy:   strategy,
+				DurationMS: result.Elapsed.Milliseconds(),
+				Scores:     result.Scores,
+			}
+			if result.Error != nil {
+				e.Error = result.Error.Error()
+			}
+			events.Emit(e)
+			return result
+		}
+
+		events.Emit(types.Event{
+			Type:     types.EventRetry,
+			URL:      url,
+			Strategy: strategy,
+			Attempt:  attempt,
+			Error:    result.Error.Error(),
+		})
+		select {
+		case <-time.After(constants.PSIRetryBackoff * time.Duration(attempt)):
+		case <-ctx.Done():
+			return result
+		}
+	}
+}
+
+// finishedEvent reports a URL as completed when at least one strategy
+// succeeded, with the mobile scores when available
+func finishedEvent(result *types.PageResult) types.Event {
+	e := types.Event{
+		Type:       types.EventFailed,
+		URL:        result.URL,
+		DurationMS: result.Duration.Milliseconds(),
+		Result:     result,
+	}
+	for _, r := range []*types.Result{result.Mobile, result.Desktop} {
+		if r == nil {
+			continue
+		}
+		if r.Error == nil {
+			e.Type = types.EventCompleted
+			e.Scores = r.Scores
+			e.Error = ""
+			return e
+		}
+		if e.Error == "" {
+			e.Error = r.Error.Error()
+		}
+	}
+	return e
+}
+
+// interrupted reports a cancelled run as an error for the progress display
+func interrupted(ctx context.Context, completed int) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	return fmt.Errorf("interrupted after %d finished URL(s)", completed)
+}
+
+// finished drops the slots of URLs that were stopped before finishing
+func finished(results []*types.PageResult) []*types.PageResult {
+	kept := results[:0]
+	for _, result := range results {
+		if result != nil {
+			kept = append(kept, result)
+		}
+	}
+	return kept
+}